@@ -0,0 +1,135 @@
+// Package address validates wallet addresses of cryptocurrencies. Addresses of
+// the known currencies are decoded and their checksums are verified, others
+// are matched by the regular expressions of the currency.
+package address
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalid is returned if the address is malformed
+	ErrInvalid = errors.New("address: invalid address")
+
+	// ErrChecksum is returned if the checksum of the address does not match,
+	// usually it is a typo
+	ErrChecksum = errors.New("address: checksum mismatch")
+
+	// ErrNetwork is returned if the address belongs to the other network,
+	// e.g. the testnet address is used in live mode
+	ErrNetwork = errors.New("address: wrong network")
+
+	// ErrTagRequired is returned if the currency requires the address tag
+	ErrTagRequired = errors.New("address: tag is required")
+
+	// ErrTagUnsupported is returned if the tag is set but the currency does
+	// not support tags
+	ErrTagUnsupported = errors.New("address: tag is not supported")
+
+	// ErrInvalidTag is returned if the tag is malformed
+	ErrInvalidTag = errors.New("address: invalid tag")
+)
+
+// Currency describes the address format of the currency, the fields match
+// fields of the moonpay.Currency
+type Currency struct {
+	Code                string
+	AddressRegex        string
+	TestnetAddressRegex string
+	SupportsAddressTag  bool
+	AddressTagRegex     string
+}
+
+// Validator checks the address in the mainnet if live is true, otherwise in
+// the testnet
+type Validator func(addr string, live bool) error
+
+var validators = map[string]Validator{
+	"btc":  bitcoin,
+	"ltc":  litecoin,
+	"bch":  bitcoinCash,
+	"dash": dash,
+	"eth":  ethereum,
+	"etc":  ethereum,
+	"usdc": ethereum,
+	"usdt": ethereum,
+	"dai":  ethereum,
+	"xrp":  ripple,
+	"xlm":  stellar,
+}
+
+// Register sets the validator of the currency code, it replaces the existing
+// one. Register is not safe to call concurrently with Validate.
+func Register(code string, v Validator) {
+	validators[strings.ToLower(code)] = v
+}
+
+// Validate checks the address of the currency in the mainnet if live is true,
+// otherwise in the testnet. The address of the currency without the validator
+// is matched by the regular expression of the currency.
+func Validate(cur Currency, addr string, live bool) error {
+	if v, ok := validators[strings.ToLower(cur.Code)]; ok {
+		return v(addr, live)
+	}
+
+	regex := cur.AddressRegex
+	if !live {
+		regex = cur.TestnetAddressRegex
+	}
+	if !match(regex, addr) {
+		return fmt.Errorf("%w: %s address does not match %s", ErrInvalid, strings.ToUpper(cur.Code), regex)
+	}
+
+	return nil
+}
+
+// ValidateTag checks the address tag of the currency, the tag is required if
+// the currency supports it
+func ValidateTag(cur Currency, tag string) error {
+	switch {
+	case !cur.SupportsAddressTag && tag != "":
+		return fmt.Errorf("%w by %s", ErrTagUnsupported, strings.ToUpper(cur.Code))
+	case !cur.SupportsAddressTag:
+		return nil
+	case tag == "":
+		return fmt.Errorf("%w by %s", ErrTagRequired, strings.ToUpper(cur.Code))
+	case !match(cur.AddressTagRegex, tag):
+		return fmt.Errorf("%w: %s tag does not match %s", ErrInvalidTag, strings.ToUpper(cur.Code), cur.AddressTagRegex)
+	}
+
+	// destination tag of XRP is the 32-bit unsigned integer
+	if strings.EqualFold(cur.Code, "xrp") {
+		if _, err := strconv.ParseUint(tag, 10, 32); err != nil {
+			return fmt.Errorf("%w: XRP tag must be a 32-bit unsigned integer", ErrInvalidTag)
+		}
+	}
+
+	return nil
+}
+
+// match reports whether the value matches the regex, the empty regex matches
+// any value
+func match(regex, value string) bool {
+	if regex == "" {
+		return true
+	}
+
+	ok, err := regexp.MatchString(regex, value)
+	return ok && err == nil
+}
+
+// network returns the error if the address of the network is used in the
+// other one
+func network(addrLive, live bool) error {
+	if addrLive == live {
+		return nil
+	}
+	if live {
+		return fmt.Errorf("%w: testnet address in live mode", ErrNetwork)
+	}
+	return fmt.Errorf("%w: mainnet address in test mode", ErrNetwork)
+}
@@ -0,0 +1,101 @@
+package address
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		code string
+		addr string
+		live bool
+		err  error
+	}{
+		// Base58Check
+		{"btc", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", true, nil},
+		{"btc", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", true, nil},
+		{"btc", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true, nil},
+		{"btc", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", true, ErrChecksum},
+		{"btc", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfN0", true, ErrInvalid},
+		{"btc", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", false, ErrNetwork},
+
+		// Bech32 and Bech32m
+		{"btc", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", true, nil},
+		{"btc", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", true, nil},
+		{"btc", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", true, nil},
+		{"btc", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", false, nil},
+		{"btc", "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", false, nil},
+		{"btc", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", true, ErrNetwork},
+		{"btc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", true, ErrChecksum},
+		{"btc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", true, ErrChecksum},
+		{"btc", "bc1Qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", true, ErrInvalid},
+
+		// CashAddr
+		{"bch", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", true, nil},
+		{"bch", "qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", true, nil},
+		{"bch", "qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", false, ErrNetwork},
+		{"bch", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6c", true, ErrChecksum},
+		{"bch", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", true, nil},
+
+		// EIP-55
+		{"eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true, nil},
+		{"eth", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", false, nil},
+		{"usdc", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", true, nil},
+		{"eth", "0xd1220a0cf47c7b9be7a2e6ba89f429762e7b9adb", true, nil},
+		{"eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", true, ErrChecksum},
+		{"eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", true, ErrInvalid},
+
+		// XRP and XLM
+		{"xrp", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", true, nil},
+		{"xrp", "rrrrrrrrrrrrrrrrrrrrrhoLvTp", false, nil},
+		{"xrp", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTj", true, ErrChecksum},
+		{"xlm", "GA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJVSGZ", true, nil},
+		{"xlm", "GA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJVSGA", true, ErrChecksum},
+		{"xlm", "SBRSG5HJAFSJ3AJ4O3ZJOGZWOXEPKOFN3M2IB5C3QKIQ3NUUTHSH2YXF", true, ErrInvalid},
+
+		// regex fallback
+		{"doge", "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L", true, nil},
+		{"doge", "invalid", true, ErrInvalid},
+	}
+
+	for _, c := range cases {
+		cur := Currency{Code: c.code, AddressRegex: `^D[1-9A-HJ-NP-Za-km-z]{33}$`}
+
+		err := Validate(cur, c.addr, c.live)
+		if c.err == nil && err != nil {
+			t.Errorf("%s %s: unexpected error %v", c.code, c.addr, err)
+		}
+		if c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%s %s: expected %v, got %v", c.code, c.addr, c.err, err)
+		}
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	xrp := Currency{Code: "xrp", SupportsAddressTag: true, AddressTagRegex: `^\d{1,10}$`}
+	btc := Currency{Code: "btc"}
+
+	cases := []struct {
+		cur Currency
+		tag string
+		err error
+	}{
+		{xrp, "12345", nil},
+		{xrp, "", ErrTagRequired},
+		{xrp, "tag", ErrInvalidTag},
+		{xrp, "9999999999", ErrInvalidTag},
+		{btc, "", nil},
+		{btc, "12345", ErrTagUnsupported},
+	}
+
+	for _, c := range cases {
+		err := ValidateTag(c.cur, c.tag)
+		if c.err == nil && err != nil {
+			t.Errorf("%s %q: unexpected error %v", c.cur.Code, c.tag, err)
+		}
+		if c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%s %q: expected %v, got %v", c.cur.Code, c.tag, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+const (
+	bitcoinAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	rippleAlphabet  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+)
+
+// base58Decode decodes the string in the alphabet, the leading zero digits
+// are decoded to zero bytes
+func base58Decode(s, alphabet string) ([]byte, error) {
+	var zeros int
+	for zeros < len(s) && s[zeros] == alphabet[0] {
+		zeros++
+	}
+
+	// little-endian base256 number
+	var num []byte
+	for i := 0; i < len(s); i++ {
+		carry := strings.IndexByte(alphabet, s[i])
+		if carry < 0 {
+			return nil, fmt.Errorf("%w: invalid base58 character %q", ErrInvalid, s[i])
+		}
+
+		for j := range num {
+			carry += int(num[j]) * 58
+			num[j] = byte(carry)
+			carry >>= 8
+		}
+		for ; carry > 0; carry >>= 8 {
+			num = append(num, byte(carry))
+		}
+	}
+
+	b := make([]byte, zeros+len(num))
+	for i, c := range num {
+		b[len(b)-1-i] = c
+	}
+
+	return b, nil
+}
+
+// base58Check decodes the string with the version byte and the double SHA-256
+// checksum
+func base58Check(s, alphabet string) (version byte, payload []byte, err error) {
+	b, err := base58Decode(s, alphabet)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(b) < 5 {
+		return 0, nil, fmt.Errorf("%w: too short", ErrInvalid)
+	}
+
+	data, sum := b[:len(b)-4], b[len(b)-4:]
+	if !bytes.Equal(doubleSHA256(data)[:4], sum) {
+		return 0, nil, ErrChecksum
+	}
+
+	return data[0], data[1:], nil
+}
+
+func doubleSHA256(b []byte) []byte {
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	return h[:]
+}
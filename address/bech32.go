@@ -0,0 +1,215 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// checksum constants of Bech32 (BIP 173) and Bech32m (BIP 350)
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// bech32Decode decodes the string, returns the human-readable part, the data
+// without the checksum and the checksum constant
+func bech32Decode(s string) (hrp string, data []byte, variant uint32, err error) {
+	if len(s) > 90 {
+		return "", nil, 0, fmt.Errorf("%w: too long", ErrInvalid)
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, fmt.Errorf("%w: mixed case", ErrInvalid)
+	}
+	s = strings.ToLower(s)
+
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, 0, fmt.Errorf("%w: invalid separator position", ErrInvalid)
+	}
+
+	hrp = s[:pos]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, fmt.Errorf("%w: invalid character in prefix", ErrInvalid)
+		}
+	}
+
+	data, err = decode5(s[pos+1:])
+	if err != nil {
+		return "", nil, 0, err
+	}
+
+	variant = bech32Polymod(append(bech32HRPExpand(hrp), data...))
+	if variant != bech32Const && variant != bech32mConst {
+		return "", nil, 0, ErrChecksum
+	}
+
+	return hrp, data[:len(data)-6], variant, nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (b>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	b := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		b = append(b, hrp[i]>>5)
+	}
+	b = append(b, 0)
+	for i := 0; i < len(hrp); i++ {
+		b = append(b, hrp[i]&31)
+	}
+
+	return b
+}
+
+// segwitDecode decodes the segregated witness address of the prefix, returns
+// the witness version and program
+func segwitDecode(s, hrp string) (version byte, program []byte, err error) {
+	prefix, data, variant, err := bech32Decode(s)
+	if err != nil {
+		return 0, nil, err
+	}
+	if prefix != hrp || len(data) == 0 {
+		return 0, nil, fmt.Errorf("%w: unexpected prefix %s", ErrInvalid, prefix)
+	}
+
+	version = data[0]
+	if version > 16 {
+		return 0, nil, fmt.Errorf("%w: invalid witness version %d", ErrInvalid, version)
+	}
+
+	program, err = convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return 0, nil, fmt.Errorf("%w: invalid witness program length %d", ErrInvalid, len(program))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return 0, nil, fmt.Errorf("%w: invalid witness program length %d", ErrInvalid, len(program))
+	}
+
+	// version 0 uses Bech32, later versions use Bech32m
+	if (version == 0) != (variant == bech32Const) {
+		return 0, nil, ErrChecksum
+	}
+
+	return version, program, nil
+}
+
+// cashaddrDecode decodes the Bitcoin Cash address with the prefix, returns the
+// version byte and the hash
+func cashaddrDecode(s, prefix string) (version byte, hash []byte, err error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return 0, nil, fmt.Errorf("%w: mixed case", ErrInvalid)
+	}
+	s = strings.ToLower(s)
+
+	data, err := decode5(s)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < 8 {
+		return 0, nil, fmt.Errorf("%w: too short", ErrInvalid)
+	}
+
+	values := make([]byte, 0, len(prefix)+1+len(data))
+	for i := 0; i < len(prefix); i++ {
+		values = append(values, prefix[i]&31)
+	}
+	values = append(values, 0)
+	values = append(values, data...)
+	if cashaddrPolymod(values) != 0 {
+		return 0, nil, ErrChecksum
+	}
+
+	payload, err := convertBits(data[:len(data)-8], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(payload) == 0 {
+		return 0, nil, fmt.Errorf("%w: empty payload", ErrInvalid)
+	}
+
+	version, hash = payload[0], payload[1:]
+	sizes := [8]int{20, 24, 28, 32, 40, 48, 56, 64}
+	if version&0x80 != 0 || len(hash) != sizes[version&7] {
+		return 0, nil, fmt.Errorf("%w: invalid hash size", ErrInvalid)
+	}
+
+	return version, hash, nil
+}
+
+func cashaddrPolymod(values []byte) uint64 {
+	gen := [5]uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+
+	c := uint64(1)
+	for _, v := range values {
+		c0 := c >> 35
+		c = (c&0x07ffffffff)<<5 ^ uint64(v)
+		for i := 0; i < 5; i++ {
+			if (c0>>uint(i))&1 == 1 {
+				c ^= gen[i]
+			}
+		}
+	}
+
+	return c ^ 1
+}
+
+// decode5 decodes characters of the Bech32 charset to 5-bit values
+func decode5(s string) ([]byte, error) {
+	data := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return nil, fmt.Errorf("%w: invalid character %q", ErrInvalid, s[i])
+		}
+		data[i] = byte(v)
+	}
+
+	return data, nil
+}
+
+// convertBits regroups bits of the values from the width to the other one
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+	maxv := uint(1)<<to - 1
+
+	var out []byte
+	for _, v := range data {
+		acc = acc<<from | uint(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxv))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxv != 0 {
+		return nil, fmt.Errorf("%w: invalid padding", ErrInvalid)
+	}
+
+	return out, nil
+}
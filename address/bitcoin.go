@@ -0,0 +1,129 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+)
+
+// chain is the address format of the Bitcoin-like network
+type chain struct {
+	name string
+
+	// versions of the Base58Check addresses
+	mainnet []byte
+	testnet []byte
+
+	// human-readable parts of the segwit addresses
+	hrp     []string
+	testHRP []string
+}
+
+var (
+	bitcoinChain = chain{
+		name:    "BTC",
+		mainnet: []byte{0x00, 0x05},
+		testnet: []byte{0x6f, 0xc4},
+		hrp:     []string{"bc"},
+		testHRP: []string{"tb", "bcrt"},
+	}
+
+	litecoinChain = chain{
+		name:    "LTC",
+		mainnet: []byte{0x30, 0x32, 0x05},
+		testnet: []byte{0x6f, 0xc4, 0x3a},
+		hrp:     []string{"ltc"},
+		testHRP: []string{"tltc", "rltc"},
+	}
+
+	dashChain = chain{
+		name:    "DASH",
+		mainnet: []byte{0x4c, 0x10},
+		testnet: []byte{0x8c, 0x13},
+	}
+)
+
+func bitcoin(addr string, live bool) error  { return bitcoinChain.validate(addr, live) }
+func litecoin(addr string, live bool) error { return litecoinChain.validate(addr, live) }
+func dash(addr string, live bool) error     { return dashChain.validate(addr, live) }
+
+func (c chain) validate(addr string, live bool) error {
+	lower := strings.ToLower(addr)
+	for _, hrp := range c.hrp {
+		if strings.HasPrefix(lower, hrp+"1") {
+			if _, _, err := segwitDecode(addr, hrp); err != nil {
+				return err
+			}
+			return network(true, live)
+		}
+	}
+	for _, hrp := range c.testHRP {
+		if strings.HasPrefix(lower, hrp+"1") {
+			if _, _, err := segwitDecode(addr, hrp); err != nil {
+				return err
+			}
+			return network(false, live)
+		}
+	}
+
+	version, payload, err := base58Check(addr, bitcoinAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 20 {
+		return fmt.Errorf("%w: invalid %s address length", ErrInvalid, c.name)
+	}
+
+	switch {
+	case containsByte(c.mainnet, version):
+		return network(true, live)
+	case containsByte(c.testnet, version):
+		return network(false, live)
+	}
+
+	return fmt.Errorf("%w: unknown %s address version %d", ErrInvalid, c.name, version)
+}
+
+// bitcoinCash validates CashAddr and legacy addresses
+func bitcoinCash(addr string, live bool) error {
+	lower := strings.ToLower(addr)
+	if !strings.Contains(lower, ":") && !strings.HasPrefix(lower, "q") && !strings.HasPrefix(lower, "p") {
+		return chain{name: "BCH", mainnet: bitcoinChain.mainnet, testnet: bitcoinChain.testnet}.validate(addr, live)
+	}
+
+	prefixes := map[string]bool{"bitcoincash": true, "bchtest": false, "bchreg": false}
+	if i := strings.IndexByte(lower, ':'); i >= 0 {
+		addrLive, ok := prefixes[lower[:i]]
+		if !ok {
+			return fmt.Errorf("%w: unknown BCH prefix %s", ErrInvalid, addr[:i])
+		}
+		if _, _, err := cashaddrDecode(addr[i+1:], lower[:i]); err != nil {
+			return err
+		}
+		return network(addrLive, live)
+	}
+
+	// the prefix is omitted, try the expected network first
+	expected, other := "bchtest", "bitcoincash"
+	if live {
+		expected, other = other, expected
+	}
+
+	_, _, err := cashaddrDecode(addr, expected)
+	if err == ErrChecksum {
+		if _, _, err := cashaddrDecode(addr, other); err == nil {
+			return network(!live, live)
+		}
+	}
+
+	return err
+}
+
+func containsByte(list []byte, b byte) bool {
+	for _, v := range list {
+		if v == b {
+			return true
+		}
+	}
+
+	return false
+}
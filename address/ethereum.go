@@ -0,0 +1,53 @@
+package address
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ethereum validates the address and its EIP-55 mixed-case checksum, the
+// address in a single case has no checksum. Test networks use the same format.
+func ethereum(addr string, live bool) error {
+	if len(addr) != 42 || !strings.HasPrefix(addr, "0x") {
+		return fmt.Errorf("%w: must be 0x followed by 40 hex digits", ErrInvalid)
+	}
+
+	digits := addr[2:]
+	if _, err := hex.DecodeString(digits); err != nil {
+		return fmt.Errorf("%w: must be 0x followed by 40 hex digits", ErrInvalid)
+	}
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+
+	if digits != eip55(digits) {
+		return ErrChecksum
+	}
+
+	return nil
+}
+
+// eip55 returns the checksummed hex address without the prefix
+func eip55(digits string) string {
+	lower := strings.ToLower(digits)
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := h.Sum(nil)
+
+	b := []byte(lower)
+	for i, c := range b {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+
+	return string(b)
+}
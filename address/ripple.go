@@ -0,0 +1,16 @@
+package address
+
+import "fmt"
+
+// ripple validates the classic XRP address, test networks use the same format
+func ripple(addr string, live bool) error {
+	version, payload, err := base58Check(addr, rippleAlphabet)
+	if err != nil {
+		return err
+	}
+	if version != 0 || len(payload) != 20 {
+		return fmt.Errorf("%w: not an XRP account address", ErrInvalid)
+	}
+
+	return nil
+}
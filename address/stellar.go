@@ -0,0 +1,46 @@
+package address
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+)
+
+// stellarAccountVersion is the version byte of the account public key, it is
+// encoded to G
+const stellarAccountVersion = 6 << 3
+
+// stellar validates the account address encoded by StrKey, test networks use
+// the same format
+func stellar(addr string, live bool) error {
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(addr)
+	if err != nil || len(b) != 35 {
+		return fmt.Errorf("%w: not a XLM account address", ErrInvalid)
+	}
+	if b[0] != stellarAccountVersion {
+		return fmt.Errorf("%w: not a XLM account address", ErrInvalid)
+	}
+
+	if crc16(b[:33]) != binary.LittleEndian.Uint16(b[33:]) {
+		return ErrChecksum
+	}
+
+	return nil
+}
+
+// crc16 is the CRC-16/XMODEM checksum
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
@@ -0,0 +1,292 @@
+package moonpay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is the exact decimal number used for money, prices and rates. The
+// zero value is 0. Amounts are immutable, arithmetic methods return new ones.
+// Compare amounts by Cmp or Equal, the == operator does not work.
+//
+// Amounts are encoded to JSON as numbers with all the digits, so values of
+// the API are kept exactly.
+type Amount struct {
+	v     *big.Int
+	scale int
+}
+
+// NewAmount returns the amount v*10^-scale, e.g. NewAmount(1050, 2) is 10.50
+func NewAmount(v int64, scale int) Amount {
+	if scale < 0 {
+		return Amount{v: new(big.Int).Mul(big.NewInt(v), pow10(-scale))}
+	}
+	return Amount{v: big.NewInt(v), scale: scale}
+}
+
+// maxAmountExponent limits the exponent of parsed amounts, large exponents
+// would allocate huge numbers
+const maxAmountExponent = 100
+
+// ParseAmount parses the decimal number, the exponent up to ±100 is allowed:
+// 1.5e-3
+func ParseAmount(s string) (Amount, error) {
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return Amount{}, fmt.Errorf("moonpay: invalid amount %q", s)
+		}
+		if e > maxAmountExponent || e < -maxAmountExponent {
+			return Amount{}, fmt.Errorf("moonpay: amount exponent is out of range %q", s)
+		}
+		mantissa, exp = s[:i], e
+	}
+
+	digits := mantissa
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits = mantissa[:i] + mantissa[i+1:]
+		exp -= len(mantissa) - i - 1
+	}
+
+	unsigned := strings.TrimLeft(digits, "+-")
+	if unsigned == "" || len(digits)-len(unsigned) > 1 || strings.Trim(unsigned, "0123456789") != "" {
+		return Amount{}, fmt.Errorf("moonpay: invalid amount %q", s)
+	}
+
+	v, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("moonpay: invalid amount %q", s)
+	}
+	if exp > 0 {
+		return Amount{v: v.Mul(v, pow10(exp))}, nil
+	}
+
+	return Amount{v: v, scale: -exp}, nil
+}
+
+// MustParseAmount is like ParseAmount but panics if the number is invalid
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// AmountFromFloat returns the amount of the shortest decimal representation
+// of the float, NaN, infinities and numbers with the exponent out of range are
+// converted to zero
+func AmountFromFloat(f float64) Amount {
+	a, _ := ParseAmount(strconv.FormatFloat(f, 'g', -1, 64))
+	return a
+}
+
+// Scale returns the number of digits after the decimal point
+func (a Amount) Scale() int {
+	return a.scale
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount
+func (a Amount) Sign() int {
+	return a.int().Sign()
+}
+
+// IsZero reports whether the amount is zero
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// Cmp compares amounts, returns -1 if a < b, 0 if a == b, +1 if a > b
+func (a Amount) Cmp(b Amount) int {
+	x, y := align(a, b)
+	return x.Cmp(y)
+}
+
+// Equal reports whether amounts are equal regardless of the scale
+func (a Amount) Equal(b Amount) bool {
+	return a.Cmp(b) == 0
+}
+
+// Add returns a+b
+func (a Amount) Add(b Amount) Amount {
+	x, y := align(a, b)
+	return Amount{v: x.Add(x, y), scale: maxInt(a.scale, b.scale)}
+}
+
+// Sub returns a-b
+func (a Amount) Sub(b Amount) Amount {
+	x, y := align(a, b)
+	return Amount{v: x.Sub(x, y), scale: maxInt(a.scale, b.scale)}
+}
+
+// Mul returns a*b with all the digits
+func (a Amount) Mul(b Amount) Amount {
+	return Amount{v: new(big.Int).Mul(a.int(), b.int()), scale: a.scale + b.scale}
+}
+
+// Div returns a/b rounded half away from zero to the places after the point,
+// it panics if b is zero
+func (a Amount) Div(b Amount, places int) Amount {
+	num := new(big.Int).Set(a.int())
+	den := new(big.Int).Set(b.int())
+
+	// a/b*10^places = a.v*10^(places-a.scale+b.scale) / b.v
+	if e := places - a.scale + b.scale; e >= 0 {
+		num.Mul(num, pow10(e))
+	} else {
+		den.Mul(den, pow10(-e))
+	}
+
+	return Amount{v: quoRound(num, den), scale: places}
+}
+
+// Neg returns -a
+func (a Amount) Neg() Amount {
+	return Amount{v: new(big.Int).Neg(a.int()), scale: a.scale}
+}
+
+// Round returns the amount rounded half away from zero to the places after
+// the point, the scale of the result is places
+func (a Amount) Round(places int) Amount {
+	if places >= a.scale {
+		return a.rescale(places)
+	}
+
+	return Amount{v: quoRound(new(big.Int).Set(a.int()), pow10(a.scale-places)), scale: places}
+}
+
+// Truncate returns the amount rounded toward zero to the places after the
+// point, the scale of the result is places
+func (a Amount) Truncate(places int) Amount {
+	if places >= a.scale {
+		return a.rescale(places)
+	}
+
+	return Amount{v: new(big.Int).Quo(a.int(), pow10(a.scale-places)), scale: places}
+}
+
+// Float64 returns the nearest float, it may lose precision
+func (a Amount) Float64() float64 {
+	f, _ := strconv.ParseFloat(a.String(), 64)
+	return f
+}
+
+// String returns the decimal representation with all the digits of the scale
+func (a Amount) String() string {
+	s := new(big.Int).Abs(a.int()).String()
+	if a.scale > 0 {
+		if len(s) <= a.scale {
+			s = strings.Repeat("0", a.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-a.scale] + "." + s[len(s)-a.scale:]
+	}
+	if a.Sign() < 0 {
+		s = "-" + s
+	}
+
+	return s
+}
+
+// MarshalJSON encodes the amount as the JSON number
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON decodes the JSON number, the number in the string and null
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*a = Amount{}
+		return nil
+	}
+
+	s := string(b)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	v, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+
+	*a = v
+	return nil
+}
+
+// Round returns the amount rounded to the precision of the currency
+func (c Currency) Round(a Amount) Amount {
+	return a.Round(c.Precision)
+}
+
+// ErrConversion is returned by Convert if the currencies cannot be converted
+var ErrConversion = errors.New("moonpay: invalid conversion")
+
+// Convert converts the amount between the fiat currency and the
+// cryptocurrency by the price of the cryptocurrency in the fiat, e.g. the
+// value of CurrencyPrice. The result is rounded to the precision of the
+// target currency.
+func (a Amount) Convert(price Amount, from, to Currency) (Amount, error) {
+	if price.Sign() <= 0 {
+		return Amount{}, fmt.Errorf("%w: price must be positive", ErrConversion)
+	}
+
+	switch {
+	case from.Type == "fiat" && to.Type == "crypto":
+		return a.Div(price, to.Precision), nil
+	case from.Type == "crypto" && to.Type == "fiat":
+		return a.Mul(price).Round(to.Precision), nil
+	}
+
+	return Amount{}, fmt.Errorf("%w: %s to %s", ErrConversion, from.Code, to.Code)
+}
+
+// int returns the unscaled value, nil is zero
+func (a Amount) int() *big.Int {
+	if a.v == nil {
+		return new(big.Int)
+	}
+	return a.v
+}
+
+// rescale returns the amount with the greater scale
+func (a Amount) rescale(scale int) Amount {
+	return Amount{v: new(big.Int).Mul(a.int(), pow10(scale-a.scale)), scale: scale}
+}
+
+// align returns unscaled values of the amounts in the common scale
+func align(a, b Amount) (*big.Int, *big.Int) {
+	scale := maxInt(a.scale, b.scale)
+	return a.rescale(scale).v, b.rescale(scale).v
+}
+
+// quoRound returns num/den rounded half away from zero
+func quoRound(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	r.Abs(r).Lsh(r, 1)
+	if r.Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
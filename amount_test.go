@@ -0,0 +1,141 @@
+package moonpay
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]string{
+		"0":            "0",
+		"100":          "100",
+		"100.50":       "100.50",
+		"-0.0051":      "-0.0051",
+		".5":           "0.5",
+		"+7":           "7",
+		"1.5e-3":       "0.0015",
+		"2E2":          "200",
+		"0.1234567891": "0.1234567891",
+		"1e100":        "1" + strings.Repeat("0", 100),
+		"1e-100":       "0." + strings.Repeat("0", 99) + "1",
+	}
+
+	for s, expected := range cases {
+		a, err := ParseAmount(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if a.String() != expected {
+			t.Errorf("%s: expected %s, got %s", s, expected, a)
+		}
+	}
+
+	for _, s := range []string{"", "-", "1.2.3", "1e", "abc", "--1", "1,5", "1e101", "1e-101", "1e999999999", "1e-999999999"} {
+		if _, err := ParseAmount(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a := MustParseAmount("0.1")
+	b := MustParseAmount("0.2")
+
+	if sum := a.Add(b); sum.String() != "0.3" || !sum.Equal(MustParseAmount("0.30")) {
+		t.Errorf("unexpected sum %s", sum)
+	}
+	if diff := a.Sub(b); diff.String() != "-0.1" || diff.Sign() != -1 {
+		t.Errorf("unexpected difference %s", diff)
+	}
+	if prod := a.Mul(b); prod.String() != "0.02" {
+		t.Errorf("unexpected product %s", prod)
+	}
+	if quo := NewAmount(1, 0).Div(NewAmount(3, 0), 4); quo.String() != "0.3333" {
+		t.Errorf("unexpected quotient %s", quo)
+	}
+	if quo := NewAmount(-2, 0).Div(NewAmount(3, 0), 2); quo.String() != "-0.67" {
+		t.Errorf("unexpected quotient %s", quo)
+	}
+
+	rounding := []struct {
+		v, round, trunc string
+	}{
+		{"1.005", "1.01", "1.00"},
+		{"-1.005", "-1.01", "-1.00"},
+		{"1.004", "1.00", "1.00"},
+		{"1.5", "1.50", "1.50"},
+	}
+	for _, c := range rounding {
+		v := MustParseAmount(c.v)
+		if r := v.Round(2); r.String() != c.round {
+			t.Errorf("%s: expected rounded %s, got %s", c.v, c.round, r)
+		}
+		if r := v.Truncate(2); r.String() != c.trunc {
+			t.Errorf("%s: expected truncated %s, got %s", c.v, c.trunc, r)
+		}
+	}
+
+	var zero Amount
+	if !zero.IsZero() || zero.String() != "0" || zero.Add(a).String() != "0.1" {
+		t.Errorf("unexpected zero value %s", zero)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var tx Transaction
+	data := `{"baseCurrencyAmount": 100.10, "quoteCurrencyAmount": "0.00512345", "feeAmount": 4.99, "extraFeeAmount": 1.00, "eurRate": 1e-1, "usdRate": null}`
+	if err := json.Unmarshal([]byte(data), &tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.BaseCurrencyAmount.String() != "100.10" || tx.QuoteCurrencyAmount.String() != "0.00512345" || tx.ExtraFeeAmount.String() != "1.00" || tx.EURrate.String() != "0.1" || !tx.USDrate.IsZero() {
+		t.Errorf("unexpected amounts %+v", tx)
+	}
+
+	b, err := json.Marshal(TransactionRequest{BaseCurrencyAmount: MustParseAmount("100.10")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req map[string]json.RawMessage
+	json.Unmarshal(b, &req)
+	if string(req["baseCurrencyAmount"]) != "100.10" || string(req["extraFeePercentage"]) != "0" {
+		t.Errorf("unexpected encoding %s", b)
+	}
+}
+
+func TestAmountConvert(t *testing.T) {
+	eur := Currency{Code: "eur", Type: "fiat", Precision: 2}
+	btc := Currency{Code: "btc", Type: "crypto", Precision: 5}
+	price := MustParseAmount("7943.51")
+
+	crypto, err := NewAmount(100, 0).Convert(price, eur, btc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.String() != "0.01259" {
+		t.Errorf("unexpected crypto amount %s", crypto)
+	}
+
+	fiat, err := crypto.Convert(price, btc, eur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fiat.String() != "100.01" {
+		t.Errorf("unexpected fiat amount %s", fiat)
+	}
+
+	if _, err := fiat.Convert(price, eur, eur); !errors.Is(err, ErrConversion) {
+		t.Errorf("expected conversion error, got %v", err)
+	}
+	if _, err := fiat.Convert(Amount{}, eur, btc); !errors.Is(err, ErrConversion) {
+		t.Errorf("expected conversion error, got %v", err)
+	}
+
+	if r := btc.Round(MustParseAmount("0.123456789")); r.String() != "0.12346" {
+		t.Errorf("unexpected rounding to the currency precision %s", r)
+	}
+}
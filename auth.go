@@ -0,0 +1,136 @@
+package moonpay
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/imroc/req"
+)
+
+// tokenRefreshBefore is the period before expiration of the customer token
+// when it is refreshed proactively
+const tokenRefreshBefore = time.Minute
+
+func authHeader(token string) req.Header {
+	return req.Header{"Authorization": "Bearer " + token}
+}
+
+// Token returns the current token of the customer, it may differ from the
+// initial one after refreshing
+func (m *MoonpayCustomer) Token() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.token
+}
+
+// ExpiresAt returns the expiration time of the token parsed from the JWT, it
+// is zero if the token does not contain expiration
+func (m *MoonpayCustomer) ExpiresAt() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.expiresAt
+}
+
+// OnTokenRefresh sets callback called after each refreshing of the token, it
+// allows to persist the new token
+func (m *MoonpayCustomer) OnTokenRefresh(fn func(CustomerAuth)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onRefresh = fn
+}
+
+func (m *MoonpayCustomer) setToken(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.token = token
+	m.expiresAt = tokenExpiry(token)
+}
+
+// do executes request authorized by the customer token. The token is refreshed
+// if it is about to expire, and the request is retried once if the token is
+// rejected by the server.
+func (m *MoonpayCustomer) do(ctx context.Context, method, url string, v ...interface{}) (*req.Resp, error) {
+	token := m.Token()
+	if exp := m.ExpiresAt(); !exp.IsZero() && time.Until(exp) < tokenRefreshBefore {
+		if err := m.renewToken(ctx, token); err != nil {
+			m.logf("moonpay: failed refresh expiring token: %v", err)
+		}
+		token = m.Token()
+	}
+
+	resp, err := m.Moonpay.do(ctx, method, url, append(v, authHeader(token))...)
+	if !isUnauthorized(err) || !replayable(v) {
+		return resp, err
+	}
+
+	if rerr := m.renewToken(ctx, token); rerr != nil {
+		m.logf("moonpay: failed refresh rejected token: %v", rerr)
+		return resp, err
+	}
+
+	return m.Moonpay.do(ctx, method, url, append(v, authHeader(m.Token()))...)
+}
+
+// renewToken refreshes the token unless it was already changed by another
+// goroutine since the old one was taken
+func (m *MoonpayCustomer) renewToken(ctx context.Context, old string) error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	if m.Token() != old {
+		return nil
+	}
+
+	_, err := m.refreshToken(ctx)
+	return err
+}
+
+func isUnauthorized(err error) bool {
+	var e *RespError
+	return errors.As(err, &e) && e.StatusCode == http.StatusUnauthorized
+}
+
+// replayable reports whether the request may be sent again, uploaded files
+// are read only once
+func replayable(v []interface{}) bool {
+	for _, vv := range v {
+		switch vv.(type) {
+		case req.FileUpload, []req.FileUpload:
+			return false
+		}
+	}
+
+	return true
+}
+
+// tokenExpiry returns expiration time of the JWT, the signature is not
+// verified since the token is issued for the server, not for the client
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,97 @@
+package moonpay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	token := srv.Login("expiry@example.com")
+	exp := tokenExpiry(token)
+	if d := time.Until(exp); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("unexpected expiration of the token: %s", exp)
+	}
+
+	if !tokenExpiry("not-a-jwt").IsZero() {
+		t.Error("expiration of the invalid token should be zero")
+	}
+}
+
+func TestTokenRefreshBeforeExpiration(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	srv.TokenTTL = 30 * time.Second
+
+	m := New(srv.PublishableKey, WithBaseURL(srv.APIURL()))
+	token := srv.Login("refresh@example.com")
+	customer := m.Customer(token)
+
+	var mu sync.Mutex
+	var refreshed []string
+	customer.OnTokenRefresh(func(c CustomerAuth) {
+		mu.Lock()
+		refreshed = append(refreshed, c.Token)
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := customer.Info(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(refreshed) == 0 {
+		t.Fatal("token is not refreshed")
+	}
+	if customer.Token() == token {
+		t.Error("token is not replaced")
+	}
+}
+
+func TestTokenRefreshOnUnauthorized(t *testing.T) {
+	var refreshes int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/v3/customers/refresh_token":
+			refreshes++
+			w.Write([]byte(`{"token":"new-token"}`))
+		case "/v3/customers/me":
+			if auth != "Bearer new-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"message":"Invalid token","name":"UnauthorizedError"}`))
+				return
+			}
+			w.Write([]byte(`{"email":"john@example.com"}`))
+		}
+	}))
+	defer srv.Close()
+
+	customer := New("", WithBaseURL(srv.URL+"/v3")).Customer("old-token")
+
+	var persisted string
+	customer.OnTokenRefresh(func(c CustomerAuth) { persisted = c.Token })
+
+	c, err := customer.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Email != "john@example.com" || refreshes != 1 || persisted != "new-token" {
+		t.Errorf("request is not retried with the refreshed token: %+v, refreshes %d, persisted %q", c, refreshes, persisted)
+	}
+}
@@ -0,0 +1,106 @@
+package moonpay
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+func TestCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	email := "cassette@example.com"
+
+	// scenario is run while recording and replaying
+	var csrf string
+	scenario := func(m *Moonpay, email, code string) Customer {
+		t.Helper()
+
+		if _, err := m.SecurityCode(email); err != nil {
+			t.Fatal(err)
+		}
+		auth, err := m.ConfirmRegistration(email, code, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		customer := m.Customer(auth.Token)
+		csrf = auth.CSRFtoken
+
+		c, err := customer.Update(CustomerFields{
+			FirstName:            "John",
+			LastName:             "Doe",
+			Phone:                "+447700900123",
+			DateOfBirth:          "1985-03-02",
+			SocialSecurityNumber: "123-45-6789",
+			Address:              &Address{Street: "221B Baker Street", Town: "Marylebone", PostCode: "NW1 6XE", Country: "GBR"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := m.CreateToken(TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := customer.CreateCard(token.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := customer.Info(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := customer.Info(); err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	}
+
+	srv := moonpaytest.NewServer()
+	rec, err := moonpaytest.NewRecorder(path, moonpaytest.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := scenario(New(srv.PublishableKey, WithBaseURL(srv.APIURL()), WithTransport(rec)), email, srv.SecurityCode)
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+	recordedCSRF := csrf
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{
+		email, srv.PublishableKey, srv.SecurityCode, `4111111111111111"`, "eyJ", `"123"`, recordedCSRF,
+		"+447700900123", "1985-03-02", "123-45-6789", "Baker Street", "Marylebone", "NW1 6XE",
+	} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("%s is not scrubbed", secret)
+		}
+	}
+
+	// the server is closed, responses are replayed
+	rec, err = moonpaytest.NewRecorder(path, moonpaytest.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(moonpaytest.ScrubbedKey, WithBaseURL(srv.APIURL()), WithTransport(rec), WithRetry(RetryPolicy{MaxAttempts: 1}))
+	replayed := scenario(m, moonpaytest.ScrubbedEmail, moonpaytest.ScrubbedCode)
+
+	if replayed.ID != recorded.ID || replayed.FirstName != "John" || replayed.Email != moonpaytest.ScrubbedEmail {
+		t.Errorf("unexpected replayed customer %+v", replayed)
+	}
+	if replayed.Phone != moonpaytest.ScrubbedPhone || replayed.SocialSecurityNumber != moonpaytest.ScrubbedSSN ||
+		replayed.DateOfBirth.Format("2006-01-02") != moonpaytest.ScrubbedDateOfBirth ||
+		replayed.Address.Street != "1 Scrubbed Street" || replayed.Address.Country != "GBR" || csrf != moonpaytest.ScrubbedCSRF {
+		t.Errorf("personal data is not scrubbed %+v", replayed)
+	}
+
+	if _, err := m.Countries(); !errors.Is(err, moonpaytest.ErrInteractionNotFound) {
+		t.Errorf("expected not found interaction, got %v", err)
+	}
+}
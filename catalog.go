@@ -0,0 +1,332 @@
+package moonpay
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCatalogTTL is the lifetime of the catalog data unless WithCatalogTTL
+// is specified
+const DefaultCatalogTTL = time.Hour
+
+// WithCatalogTTL sets the lifetime of the data cached by the client catalog,
+// DefaultCatalogTTL is used if ttl is not positive
+func WithCatalogTTL(ttl time.Duration) Option {
+	return func(m *Moonpay) {
+		if ttl <= 0 {
+			ttl = DefaultCatalogTTL
+		}
+		m.catalogTTL = ttl
+	}
+}
+
+// CurrencyFilter selects currencies of the catalog
+type CurrencyFilter func(Currency) bool
+
+var (
+	// FilterCrypto selects cryptocurrencies
+	FilterCrypto CurrencyFilter = func(c Currency) bool { return c.Type == "crypto" }
+
+	// FilterFiat selects fiat currencies
+	FilterFiat CurrencyFilter = func(c Currency) bool { return c.Type == "fiat" }
+
+	// FilterTestMode selects currencies supported in the test mode
+	FilterTestMode CurrencyFilter = func(c Currency) bool { return c.SupportsTestMode }
+
+	// FilterUS selects currencies supported in the US
+	FilterUS CurrencyFilter = func(c Currency) bool { return c.IsSupportedInUS }
+
+	// FilterActive selects currencies which are not suspended
+	FilterActive CurrencyFilter = func(c Currency) bool { return !c.IsSuspended }
+)
+
+// Catalog caches currencies and countries. The data is loaded on the first
+// lookup, expired data is refreshed in the background and served stale until
+// the refresh succeeds, so lookups keep working when the API is unavailable.
+// The embedded snapshot is empty unless it is generated from the live API by
+// go generate, only then the catalog falls back to it if the first loading
+// fails.
+type Catalog struct {
+	m        *Moonpay
+	ttl      time.Duration
+	snapshot *Snapshot
+
+	mu   sync.RWMutex
+	data *catalogData
+
+	// loadMu serializes the initial loading, refreshing is set while the
+	// background refresh is running
+	loadMu     sync.Mutex
+	refreshing int32
+}
+
+// catalogData is the immutable snapshot of the catalog
+type catalogData struct {
+	currencies []Currency
+	countries  []Country
+	loadedAt   time.Time
+	snapshot   bool
+
+	byCode   map[string]int
+	byID     map[uuid.UUID]int
+	byAlpha2 map[string]int
+	byAlpha3 map[string]int
+}
+
+// NewCatalog returns an empty catalog loading data by the client,
+// DefaultCatalogTTL is used if ttl is not positive
+func NewCatalog(m *Moonpay, ttl time.Duration) *Catalog {
+	if ttl <= 0 {
+		ttl = DefaultCatalogTTL
+	}
+	c := &Catalog{m: m, ttl: ttl}
+	if s, err := EmbeddedSnapshot(); err == nil && s.valid() {
+		c.snapshot = &s
+	}
+
+	return c
+}
+
+// Catalog returns the catalog shared by the client
+func (m *Moonpay) Catalog() *Catalog {
+	return m.catalog
+}
+
+// Refresh loads currencies and countries, the cached data is kept if loading
+// fails
+func (c *Catalog) Refresh(ctx context.Context) error {
+	currencies, err := c.m.CurrenciesContext(ctx)
+	if err != nil {
+		return err
+	}
+	countries, err := c.m.CountriesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.set(newCatalogData(currencies, countries, time.Now()))
+	return nil
+}
+
+// Run refreshes the catalog each TTL until the context is done
+func (c *Catalog) Run(ctx context.Context) {
+	t := time.NewTicker(c.ttl)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+				c.m.logf("moonpay: failed refresh catalog: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// LoadedAt returns the time the cached data was loaded or the snapshot was
+// generated, zero if the catalog is not loaded yet
+func (c *Catalog) LoadedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.data == nil {
+		return time.Time{}
+	}
+	return c.data.loadedAt
+}
+
+// FromSnapshot reports whether the catalog serves the embedded snapshot
+// because the API has not been reached yet
+func (c *Catalog) FromSnapshot() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.data != nil && c.data.snapshot
+}
+
+// Currencies returns currencies selected by all the filters
+func (c *Catalog) Currencies(ctx context.Context, filters ...CurrencyFilter) ([]Currency, error) {
+	d, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Currency, 0, len(d.currencies))
+next:
+	for _, cur := range d.currencies {
+		for _, filter := range filters {
+			if !filter(cur) {
+				continue next
+			}
+		}
+		list = append(list, cur)
+	}
+
+	return list, nil
+}
+
+// Countries returns all countries
+func (c *Catalog) Countries(ctx context.Context) ([]Country, error) {
+	d, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]Country(nil), d.countries...), nil
+}
+
+// CurrencyByCode returns the currency by the case-insensitive code or
+// ErrNotFound
+func (c *Catalog) CurrencyByCode(ctx context.Context, code string) (Currency, error) {
+	d, err := c.get(ctx)
+	if err != nil {
+		return Currency{}, err
+	}
+
+	i, ok := d.byCode[strings.ToLower(code)]
+	if !ok {
+		return Currency{}, ErrNotFound
+	}
+	return d.currencies[i], nil
+}
+
+// CurrencyByID returns the currency by the identifier, e.g. CurrencyID of the
+// transaction, or ErrNotFound
+func (c *Catalog) CurrencyByID(ctx context.Context, id uuid.UUID) (Currency, error) {
+	d, err := c.get(ctx)
+	if err != nil {
+		return Currency{}, err
+	}
+
+	i, ok := d.byID[id]
+	if !ok {
+		return Currency{}, ErrNotFound
+	}
+	return d.currencies[i], nil
+}
+
+// CountryByAlpha2 returns the country by the ISO 3166-1 alpha-2 code or
+// ErrNotFound
+func (c *Catalog) CountryByAlpha2(ctx context.Context, code string) (Country, error) {
+	d, err := c.get(ctx)
+	if err != nil {
+		return Country{}, err
+	}
+
+	i, ok := d.byAlpha2[strings.ToUpper(code)]
+	if !ok {
+		return Country{}, ErrNotFound
+	}
+	return d.countries[i], nil
+}
+
+// CountryByAlpha3 returns the country by the ISO 3166-1 alpha-3 code or
+// ErrNotFound
+func (c *Catalog) CountryByAlpha3(ctx context.Context, code string) (Country, error) {
+	d, err := c.get(ctx)
+	if err != nil {
+		return Country{}, err
+	}
+
+	i, ok := d.byAlpha3[strings.ToUpper(code)]
+	if !ok {
+		return Country{}, ErrNotFound
+	}
+	return d.countries[i], nil
+}
+
+// get returns the cached data, loads it if the catalog is empty and starts
+// the background refresh if the data is expired
+func (c *Catalog) get(ctx context.Context) (*catalogData, error) {
+	c.mu.RLock()
+	d := c.data
+	c.mu.RUnlock()
+
+	if d == nil {
+		return c.load(ctx)
+	}
+	if time.Since(d.loadedAt) > c.ttl {
+		c.refreshAsync()
+	}
+
+	return d, nil
+}
+
+// load loads the empty catalog, concurrent callers wait for the single request
+func (c *Catalog) load(ctx context.Context) (*catalogData, error) {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+
+	c.mu.RLock()
+	d := c.data
+	c.mu.RUnlock()
+	if d != nil {
+		return d, nil
+	}
+
+	if err := c.Refresh(ctx); err != nil {
+		if c.snapshot == nil {
+			return nil, err
+		}
+
+		c.m.logf("moonpay: failed load catalog, serving snapshot of %s: %v", c.snapshot.GeneratedAt.Format(time.RFC3339), err)
+		d := newCatalogData(c.snapshot.Currencies, c.snapshot.Countries, c.snapshot.GeneratedAt)
+		d.snapshot = true
+		c.set(d)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data, nil
+}
+
+func (c *Catalog) refreshAsync() {
+	if !atomic.CompareAndSwapInt32(&c.refreshing, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&c.refreshing, 0)
+
+		if err := c.Refresh(context.Background()); err != nil {
+			c.m.logf("moonpay: failed refresh catalog, serving stale data: %v", err)
+		}
+	}()
+}
+
+func (c *Catalog) set(d *catalogData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data = d
+}
+
+func newCatalogData(currencies []Currency, countries []Country, loadedAt time.Time) *catalogData {
+	d := &catalogData{
+		currencies: currencies,
+		countries:  countries,
+		loadedAt:   loadedAt,
+		byCode:     make(map[string]int, len(currencies)),
+		byID:       make(map[uuid.UUID]int, len(currencies)),
+		byAlpha2:   make(map[string]int, len(countries)),
+		byAlpha3:   make(map[string]int, len(countries)),
+	}
+
+	for i, cur := range currencies {
+		d.byCode[strings.ToLower(cur.Code)] = i
+		d.byID[cur.ID] = i
+	}
+	for i, country := range countries {
+		d.byAlpha2[strings.ToUpper(country.Alpha2)] = i
+		d.byAlpha3[strings.ToUpper(country.Alpha3)] = i
+	}
+
+	return d
+}
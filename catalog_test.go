@@ -0,0 +1,170 @@
+package moonpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+func TestCatalog(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	catalog := New(srv.PublishableKey, WithBaseURL(srv.APIURL())).Catalog()
+
+	btc, err := catalog.CurrencyByCode(ctx, "BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur, err := catalog.CurrencyByID(ctx, btc.ID); err != nil || cur.Code != "btc" {
+		t.Errorf("unexpected currency %s by ID: %v", cur.Code, err)
+	}
+	if _, err := catalog.CurrencyByCode(ctx, "doge"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if country, err := catalog.CountryByAlpha2(ctx, "gb"); err != nil || country.Alpha3 != "GBR" {
+		t.Errorf("unexpected country %s: %v", country.Alpha3, err)
+	}
+	if country, err := catalog.CountryByAlpha3(ctx, "DEU"); err != nil || country.Alpha2 != "DE" {
+		t.Errorf("unexpected country %s: %v", country.Alpha2, err)
+	}
+
+	list, err := catalog.Currencies(ctx, FilterCrypto, FilterTestMode, FilterUS, FilterActive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cur := range list {
+		if cur.Type != "crypto" || !cur.SupportsTestMode || !cur.IsSupportedInUS || cur.IsSuspended {
+			t.Errorf("unexpected currency %s selected", cur.Code)
+		}
+	}
+	if fiat, _ := catalog.Currencies(ctx, FilterFiat); len(fiat) != 3 {
+		t.Errorf("expected 3 fiat currencies, got %d", len(fiat))
+	}
+}
+
+func TestCatalogStale(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	catalog := New(srv.PublishableKey,
+		WithBaseURL(srv.APIURL()),
+		WithRetry(RetryPolicy{MaxAttempts: 1}),
+		WithCatalogTTL(10*time.Millisecond),
+	).Catalog()
+
+	if _, err := catalog.CurrencyByCode(ctx, "btc"); err != nil {
+		t.Fatal(err)
+	}
+	loadedAt := catalog.LoadedAt()
+
+	// the expired data is served while the API fails
+	time.Sleep(20 * time.Millisecond)
+	srv.FailNext(1, http.StatusServiceUnavailable)
+	if _, err := catalog.CurrencyByCode(ctx, "btc"); err != nil {
+		t.Fatalf("stale data is not served: %v", err)
+	}
+
+	// the next lookup refreshes the data in the background
+	srv.SetCurrencies(moonpaytest.Currencies()[:3])
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := catalog.CurrencyByCode(ctx, "btc"); errors.Is(err, ErrNotFound) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("catalog is not refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !catalog.LoadedAt().After(loadedAt) {
+		t.Error("loading time is not updated")
+	}
+}
+
+func TestCatalogTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		m := New("pk_test_key", WithCatalogTTL(ttl))
+		if m.Catalog().ttl != DefaultCatalogTTL {
+			t.Errorf("%s: unexpected TTL %s", ttl, m.Catalog().ttl)
+		}
+		if c := NewCatalog(m, ttl); c.ttl != DefaultCatalogTTL {
+			t.Errorf("%s: unexpected TTL %s of the new catalog", ttl, c.ttl)
+		}
+	}
+
+	// Run does not panic with the default TTL
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewCatalog(New("pk_test_key"), 0).Run(ctx)
+}
+
+func TestCatalogSnapshot(t *testing.T) {
+	embedded, err := EmbeddedSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embedded.Version != SnapshotVersion || embedded.Source == fakeSource {
+		t.Fatalf("invalid embedded snapshot version %d from %q", embedded.Version, embedded.Source)
+	}
+
+	// the committed snapshot is the empty placeholder, a generated one must be
+	// valid to be shipped
+	if len(embedded.Currencies) > 0 && !embedded.valid() {
+		t.Errorf("embedded snapshot of %q is not valid", embedded.Source)
+	}
+	if c := NewCatalog(New("pk_test_key"), 0); (c.snapshot != nil) != embedded.valid() {
+		t.Errorf("catalog fallback %t does not match the embedded snapshot", c.snapshot != nil)
+	}
+
+	snapshot := Snapshot{
+		Version:     SnapshotVersion,
+		GeneratedAt: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		Source:      apiAddr,
+		Currencies:  []Currency{{ID: uuid.New(), Type: "crypto", Code: "btc"}},
+	}
+	for _, c := range []struct {
+		name  string
+		edit  func(s *Snapshot)
+		valid bool
+	}{
+		{"live", func(s *Snapshot) {}, true},
+		{"fake", func(s *Snapshot) { s.Source = fakeSource }, false},
+		{"empty", func(s *Snapshot) { s.Currencies = nil }, false},
+		{"version", func(s *Snapshot) { s.Version++ }, false},
+	} {
+		s := snapshot
+		c.edit(&s)
+		if s.valid() != c.valid {
+			t.Errorf("%s: valid is %t", c.name, !c.valid)
+		}
+	}
+
+	srv := moonpaytest.NewServer()
+	srv.Close()
+
+	ctx := context.Background()
+	catalog := New(srv.PublishableKey,
+		WithBaseURL(srv.APIURL()),
+		WithRetry(RetryPolicy{MaxAttempts: 1}),
+	).Catalog()
+	catalog.snapshot = &snapshot
+
+	if _, err := catalog.CurrencyByCode(ctx, "btc"); err != nil {
+		t.Fatalf("snapshot is not served: %v", err)
+	}
+	if !catalog.FromSnapshot() {
+		t.Error("catalog is not loaded from the snapshot")
+	}
+	if !catalog.LoadedAt().Equal(snapshot.GeneratedAt) {
+		t.Errorf("loading time %s does not match the snapshot %s", catalog.LoadedAt(), snapshot.GeneratedAt)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sg3des/moonpay"
+)
+
+func currencies(a *app, ctx context.Context, args []string) error {
+	if _, err := a.parse(a.flags("currencies"), args); err != nil {
+		return err
+	}
+
+	list, err := a.mpay.CurrenciesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"CODE", "NAME", "TYPE", "PRECISION", "TEST MODE", "US", "SUSPENDED", "ID"}}
+	for _, c := range list {
+		t.add(c.Code, c.Name, c.Type, strconv.Itoa(c.Precision), formatBool(c.SupportsTestMode), formatBool(c.IsSupportedInUS), formatBool(c.IsSuspended), c.ID.String())
+	}
+
+	return a.print(list, t)
+}
+
+func countries(a *app, ctx context.Context, args []string) error {
+	if _, err := a.parse(a.flags("countries"), args); err != nil {
+		return err
+	}
+
+	list, err := a.mpay.CountriesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"ALPHA2", "ALPHA3", "NAME", "ALLOWED", "DOCUMENTS"}}
+	for _, c := range list {
+		t.add(c.Alpha2, c.Alpha3, c.Name, formatBool(c.IsAllowed), strings.Join(c.SupportedDocuments, ","))
+	}
+
+	return a.print(list, t)
+}
+
+func price(a *app, ctx context.Context, args []string) error {
+	fs := a.flags("price")
+	fiat := fs.String("fiat", "", "comma-separated fiat currencies, default is the config fiat or eur")
+
+	crypto, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(crypto) == 0 {
+		return errors.New("usage: price <crypto>... [-fiat eur,gbp]")
+	}
+	if *fiat == "" {
+		*fiat = a.fiat()
+	}
+
+	prices, err := a.mpay.CurrenciesPriceContext(ctx, crypto, strings.Split(*fiat, ","))
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"CRYPTO", "FIAT", "PRICE"}}
+	for _, code := range sortedKeys(prices) {
+		rates := prices[code]
+
+		fiats := make([]string, 0, len(rates))
+		for f := range rates {
+			fiats = append(fiats, f)
+		}
+		sort.Strings(fiats)
+
+		for _, f := range fiats {
+			t.add(code, f, rates[f].String())
+		}
+	}
+
+	return a.print(prices, t)
+}
+
+func ipAddress(a *app, ctx context.Context, args []string) error {
+	if _, err := a.parse(a.flags("ip"), args); err != nil {
+		return err
+	}
+
+	ip, err := a.mpay.IPaddressContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"IP", "ALPHA2", "ALPHA3", "STATE", "ALLOWED"}}
+	t.add(ip.IPaddress, ip.Alpha2, ip.Alpha3, ip.State, formatBool(ip.IsAllowed))
+
+	return a.print(ip, t)
+}
+
+func login(a *app, ctx context.Context, args []string) error {
+	fs := a.flags("login")
+	extid := fs.String("external-id", "", "external customer ID")
+
+	pos, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return errors.New("usage: login <email> [-external-id id]")
+	}
+	email := pos[0]
+
+	if _, err := a.mpay.SecurityCodeContext(ctx, email); err != nil {
+		return err
+	}
+
+	code, err := a.prompt(fmt.Sprintf("security code sent to %s: ", email))
+	if err != nil {
+		return err
+	}
+
+	auth, err := a.mpay.ConfirmRegistrationContext(ctx, email, code, *extid)
+	if err != nil {
+		return err
+	}
+	if err := a.saveSession(auth); err != nil {
+		return fmt.Errorf("failed save session: %w", err)
+	}
+
+	// the token is kept in the profile only
+	t := table{header: []string{"ID", "EMAIL", "PROFILE"}}
+	t.add(auth.Customer.ID.String(), auth.Customer.Email, a.name)
+
+	return a.print(auth.Customer, t)
+}
+
+func sortedKeys(m map[string]map[string]moonpay.Amount) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
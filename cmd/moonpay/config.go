@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// defaultProfile is the name of the profile used if none is selected
+const defaultProfile = "default"
+
+// config is the content of the config file
+type config struct {
+	// Current is the name of the profile selected by "profile use"
+	Current string `json:"profile,omitempty"`
+
+	// Salt is the salt of the key derivation of the tokens encryption
+	Salt []byte `json:"salt,omitempty"`
+
+	Profiles map[string]*profile `json:"profiles,omitempty"`
+}
+
+// profile is the named set of the API credentials
+type profile struct {
+	Key  string `json:"key"`
+	URL  string `json:"url,omitempty"`
+	Fiat string `json:"fiat,omitempty"`
+
+	// Customer is the ID of the current session
+	Customer string              `json:"customer,omitempty"`
+	Sessions map[string]*session `json:"sessions,omitempty"`
+}
+
+// session is the saved customer authentication, the token is encrypted
+type session struct {
+	Email      string    `json:"email,omitempty"`
+	ExternalID string    `json:"externalId,omitempty"`
+	Token      string    `json:"token"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// live reports whether the profile uses the live key
+func (p *profile) live() bool {
+	return isLiveKey(p.Key)
+}
+
+// session returns the session by the customer ID, the email or the external
+// customer ID
+func (p *profile) session(id string) (string, *session, bool) {
+	if s, ok := p.Sessions[id]; ok {
+		return id, s, true
+	}
+	for cid, s := range p.Sessions {
+		if strings.EqualFold(s.Email, id) || (s.ExternalID != "" && s.ExternalID == id) {
+			return cid, s, true
+		}
+	}
+
+	return "", nil, false
+}
+
+func isLiveKey(key string) bool {
+	return strings.HasPrefix(key, "pk_live_")
+}
+
+// configFile returns path of the config file
+func (a *app) configFile() string {
+	if a.configPath != "" {
+		return a.configPath
+	}
+	if path := a.getenv("MOONPAY_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "moonpay", "config.json")
+}
+
+// profileName returns the name of the selected profile
+func (a *app) profileName(cfg config) string {
+	switch {
+	case a.profile != "":
+		return a.profile
+	case a.getenv("MOONPAY_PROFILE") != "":
+		return a.getenv("MOONPAY_PROFILE")
+	case cfg.Current != "":
+		return cfg.Current
+	}
+
+	return defaultProfile
+}
+
+// loadConfig reads the config file, the missing file is the empty config
+func loadConfig(path string) (config, error) {
+	var cfg config
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// saveConfig writes the config to the temporary file and renames it, the file
+// is readable by the owner only
+func saveConfig(path string, cfg config) error {
+	if path == "" {
+		return errors.New("config file is not set, use -config flag or MOONPAY_CONFIG")
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+//
+// Encryption
+//
+
+// scrypt parameters of the key derivation
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// cipher returns AES-GCM of the key derived by scrypt from the passphrase and
+// the salt of the config. The passphrase is MOONPAY_SECRET or it is asked, the
+// salt is generated if the config has none and the caller must save it.
+func (a *app) cipher(cfg *config) (cipher.AEAD, error) {
+	if len(cfg.Salt) == 0 {
+		cfg.Salt = make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, cfg.Salt); err != nil {
+			return nil, err
+		}
+	}
+	if a.aead != nil && bytes.Equal(a.salt, cfg.Salt) {
+		return a.aead, nil
+	}
+
+	secret := a.getenv("MOONPAY_SECRET")
+	if secret == "" {
+		var err error
+		if secret, err = a.password("passphrase of saved sessions: "); err != nil {
+			return nil, err
+		}
+	}
+	if secret == "" {
+		return nil, errors.New("passphrase of saved sessions is empty, set MOONPAY_SECRET")
+	}
+
+	key, err := scrypt.Key([]byte(secret), cfg.Salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	a.aead, a.salt = aead, cfg.Salt
+
+	return aead, nil
+}
+
+// encrypt seals the token, the nonce is prepended to the ciphertext
+func encrypt(aead cipher.AEAD, token string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(token), nil)), nil
+}
+
+func decrypt(aead cipher.AEAD, s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+
+	n := aead.NonceSize()
+	if len(data) < n {
+		return "", errors.New("encrypted token is too short")
+	}
+
+	plain, err := aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed decrypt token, the passphrase is wrong: %w", err)
+	}
+
+	return string(plain), nil
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sg3des/moonpay"
+	"github.com/sg3des/moonpay/export"
+)
+
+func customer(a *app, ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: customer info|limits|update")
+	}
+
+	switch args[0] {
+	case "info":
+		return customerInfo(a, ctx, args[1:])
+	case "limits":
+		return customerLimits(a, ctx, args[1:])
+	case "update":
+		return customerUpdate(a, ctx, args[1:])
+	}
+
+	return fmt.Errorf("unknown customer command %q", args[0])
+}
+
+func customerInfo(a *app, ctx context.Context, args []string) error {
+	if _, err := a.parse(a.flags("customer info"), args); err != nil {
+		return err
+	}
+	c, err := a.customer()
+	if err != nil {
+		return err
+	}
+
+	info, err := c.InfoContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.print(info, customerTable(info))
+}
+
+func customerLimits(a *app, ctx context.Context, args []string) error {
+	if _, err := a.parse(a.flags("customer limits"), args); err != nil {
+		return err
+	}
+	c, err := a.customer()
+	if err != nil {
+		return err
+	}
+
+	limits, err := c.LimitsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"TYPE", "DAILY", "DAILY REMAINING", "MONTHLY", "MONTHLY REMAINING"}}
+	for _, l := range limits.Limits {
+		t.add(l.Type, strconv.Itoa(l.DailyLimit), strconv.Itoa(l.DailyLimitRemaining), strconv.Itoa(l.MonthlyLimit), strconv.Itoa(l.MonthlyLimitRemaining))
+	}
+
+	return a.print(limits, t)
+}
+
+func customerUpdate(a *app, ctx context.Context, args []string) error {
+	var fields moonpay.CustomerFields
+	var addr moonpay.Address
+
+	fs := a.flags("customer update")
+	fs.StringVar(&fields.FirstName, "first-name", "", "first name")
+	fs.StringVar(&fields.LastName, "last-name", "", "last name")
+	fs.StringVar(&fields.Email, "email", "", "email")
+	fs.StringVar(&fields.Phone, "phone", "", "phone number")
+	fs.StringVar(&fields.DateOfBirth, "dob", "", "date of birth, YYYY-MM-DD")
+	fs.StringVar(&addr.Street, "street", "", "address street")
+	fs.StringVar(&addr.Town, "town", "", "address town")
+	fs.StringVar(&addr.PostCode, "postcode", "", "address post code")
+	fs.StringVar(&addr.State, "state", "", "address state")
+	fs.StringVar(&addr.Country, "country", "", "address country, ISO 3166-1 alpha-3")
+
+	if _, err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if addr != (moonpay.Address{}) {
+		fields.Address = &addr
+	}
+	if err := a.confirm("update the customer"); err != nil {
+		return err
+	}
+
+	c, err := a.customer()
+	if err != nil {
+		return err
+	}
+
+	info, err := c.UpdateContext(ctx, fields)
+	if err != nil {
+		return err
+	}
+
+	return a.print(info, customerTable(info))
+}
+
+func customerTable(c moonpay.Customer) table {
+	var dob string
+	if !c.DateOfBirth.IsZero() {
+		dob = c.DateOfBirth.Format("2006-01-02")
+	}
+
+	t := table{header: []string{"ID", "EMAIL", "FIRST NAME", "LAST NAME", "PHONE", "DATE OF BIRTH", "COUNTRY", "LIVE", "EXTERNAL ID"}}
+	t.add(c.ID.String(), c.Email, c.FirstName, c.LastName, c.Phone, dob, c.Address.Country, formatBool(c.LiveMode), c.ExternalCustomerID)
+
+	return t
+}
+
+//
+// Cards
+//
+
+func cards(a *app, ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: cards list|delete <id>")
+	}
+
+	pos, err := a.parse(a.flags("cards "+args[0]), args[1:])
+	if err != nil {
+		return err
+	}
+	c, err := a.customer()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		list, err := c.CardsContext(ctx)
+		if err != nil {
+			return err
+		}
+		return a.print(list, cardsTable(list...))
+
+	case "delete":
+		if len(pos) != 1 {
+			return errors.New("usage: cards delete <id>")
+		}
+		id, err := uuid.Parse(pos[0])
+		if err != nil {
+			return fmt.Errorf("invalid card ID: %w", err)
+		}
+		if err := a.confirm("delete the card"); err != nil {
+			return err
+		}
+
+		card, err := c.DeleteCardContext(ctx, id)
+		if err != nil {
+			return err
+		}
+		return a.print(card, cardsTable(card))
+	}
+
+	return fmt.Errorf("unknown cards command %q", args[0])
+}
+
+func cardsTable(list ...moonpay.Card) table {
+	t := table{header: []string{"ID", "BRAND", "BIN", "LAST DIGITS", "EXPIRY", "CREATED"}}
+	for _, c := range list {
+		t.add(c.ID.String(), c.Brand, c.Bin, c.LastDigits, fmt.Sprintf("%02d/%d", c.ExpiryMonth, c.ExpiryYear), formatTime(c.CreatedAt))
+	}
+
+	return t
+}
+
+//
+// Transactions
+//
+
+func transactions(a *app, ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: tx list|get <id>|create|export")
+	}
+
+	switch args[0] {
+	case "list":
+		return txList(a, ctx, args[1:])
+	case "get":
+		return txGet(a, ctx, args[1:])
+	case "create":
+		return txCreate(a, ctx, args[1:])
+	case "export":
+		return txExport(a, ctx, args[1:])
+	}
+
+	return fmt.Errorf("unknown tx command %q", args[0])
+}
+
+func txList(a *app, ctx context.Context, args []string) error {
+	fs := a.flags("tx list")
+	filter := txFilter(fs)
+
+	if _, err := a.parse(fs, args); err != nil {
+		return err
+	}
+	f, err := filter()
+	if err != nil {
+		return err
+	}
+	c, err := a.customer()
+	if err != nil {
+		return err
+	}
+
+	// the single page is requested if the limit is set
+	var list []moonpay.Transaction
+	if f.Limit > 0 {
+		list, err = c.ListTransactionsContext(ctx, f)
+	} else {
+		s := c.ScanTransactionsContext(ctx, f)
+		for s.Scan() {
+			list = append(list, s.Transaction())
+		}
+		err = s.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	return a.print(list, a.txTable(ctx, list...))
+}
+
+// txFilter registers flags of the transaction filter, the returned function
+// parses them after the flags are parsed
+func txFilter(fs *flag.FlagSet) func() (moonpay.TransactionFilter, error) {
+	var f moonpay.TransactionFilter
+	var status, from, to, external string
+
+	fs.StringVar(&status, "status", "", "comma-separated statuses")
+	fs.StringVar(&from, "from", "", "transactions created since the date, YYYY-MM-DD")
+	fs.StringVar(&to, "to", "", "transactions created before the date, YYYY-MM-DD")
+	fs.StringVar(&f.CurrencyCode, "crypto", "", "cryptocurrency code")
+	fs.StringVar(&f.BaseCurrencyCode, "fiat", "", "fiat currency code")
+	fs.StringVar(&external, "external-id", "", "comma-separated external transaction IDs")
+	fs.IntVar(&f.Limit, "limit", 0, "number of transactions, all if 0")
+	fs.IntVar(&f.Offset, "offset", 0, "number of the newest transactions to skip")
+
+	return func() (moonpay.TransactionFilter, error) {
+		for _, v := range []struct {
+			t    *time.Time
+			date string
+		}{{&f.From, from}, {&f.To, to}} {
+			if v.date == "" {
+				continue
+			}
+
+			t, err := time.Parse("2006-01-02", v.date)
+			if err != nil {
+				return f, fmt.Errorf("invalid date %q: %w", v.date, err)
+			}
+			*v.t = t
+		}
+
+		if status != "" {
+			for _, s := range strings.Split(status, ",") {
+				st := moonpay.TransactionStatus(strings.TrimSpace(s))
+				if !st.IsKnown() {
+					return f, fmt.Errorf("unknown status %q", s)
+				}
+				f.Status = append(f.Status, st)
+			}
+		}
+		if external != "" {
+			f.ExternalTransactionIDs = strings.Split(external, ",")
+		}
+
+		return f, nil
+	}
+}
+
+func txGet(a *app, ctx context.Context, args []string) error {
+	fs := a.flags("tx get")
+	external := fs.Bool("external", false, "get by the external transaction ID")
+
+	pos, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return errors.New("usage: tx get <id> [-external]")
+	}
+	c, err := a.customer()
+	if err != nil {
+		return err
+	}
+
+	var tx moonpay.Transaction
+	if *external {
+		tx, err = c.TransactionByExternalIDContext(ctx, pos[0])
+	} else {
+		id, perr := uuid.Parse(pos[0])
+		if perr != nil {
+			return fmt.Errorf("invalid transaction ID: %w", perr)
+		}
+		tx, err = c.TransactionContext(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	return a.print(tx, a.txTable(ctx, tx))
+}
+
+func txCreate(a *app, ctx context.Context, args []string) error {
+	var data moonpay.TransactionRequest
+	var amount, fee string
+
+	fs := a.flags("tx create")
+	fs.StringVar(&amount, "amount", "", "amount in the fiat currency")
+	fs.StringVar(&fee, "fee", "", "extra fee percentage")
+	fs.StringVar(&data.BaseCurrencyCode, "fiat", "", "fiat currency, default is the config fiat or eur")
+	fs.StringVar(&data.CurrencyCode, "crypto", "", "cryptocurrency to buy")
+	fs.StringVar(&data.WalletAddress, "wallet", "", "wallet address")
+	fs.StringVar(&data.WalletAddressTag, "tag", "", "wallet address tag")
+	fs.StringVar(&data.CardID, "card", "", "ID of the saved card")
+	fs.StringVar(&data.TokenID, "card-token", "", "ID of the card token")
+	fs.StringVar(&data.ExternalTransactionID, "external-id", "", "external transaction ID, generated if empty and printed on the failure")
+	fs.StringVar(&data.ReturnURL, "return-url", "", "URL to return after the 3D Secure authorization")
+	fs.BoolVar(&data.AreFeesIncluded, "fees-included", false, "fees are included in the amount")
+
+	if _, err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	var err error
+	if data.BaseCurrencyAmount, err = moonpay.ParseAmount(amount); err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	if fee != "" {
+		if data.ExtraFeeAmount, err = moonpay.ParseAmount(fee); err != nil {
+			return fmt.Errorf("invalid fee: %w", err)
+		}
+	}
+	if data.BaseCurrencyCode == "" {
+		data.BaseCurrencyCode = a.fiat()
+	}
+	if err := a.confirm(fmt.Sprintf("buy %s for %s %s", data.CurrencyCode, amount, data.BaseCurrencyCode)); err != nil {
+		return err
+	}
+
+	c, err := a.customer()
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.CreateTransactionContext(ctx, data)
+	if err != nil {
+		return fmt.Errorf("%w, external ID %s", err, tx.ExternalTransactionID)
+	}
+
+	return a.print(tx, a.txTable(ctx, tx))
+}
+
+func (a *app) txTable(ctx context.Context, list ...moonpay.Transaction) table {
+	code := func(id uuid.UUID) string {
+		if c, err := a.mpay.Catalog().CurrencyByID(ctx, id); err == nil {
+			return c.Code
+		}
+		return id.String()
+	}
+
+	t := table{header: []string{"ID", "CREATED", "STATUS", "AMOUNT", "FIAT", "CRYPTO AMOUNT", "CRYPTO", "FEE", "WALLET", "EXTERNAL ID"}}
+	for _, tx := range list {
+		t.add(tx.ID.String(), formatTime(tx.CreatedAt), string(tx.Status),
+			tx.BaseCurrencyAmount.String(), code(tx.BaseCurrencyID),
+			tx.QuoteCurrencyAmount.String(), code(tx.CurrencyID),
+			tx.FeeAmount.String(), tx.WalletAddress, tx.ExternalTransactionID)
+	}
+
+	return t
+}
+
+func txExport(a *app, ctx context.Context, args []string) error {
+	fs := a.flags("tx export")
+	output := fs.String("o", "", "output file, default is stdout")
+	filter := txFilter(fs)
+
+	if _, err := a.parse(fs, args); err != nil {
+		return err
+	}
+	f, err := filter()
+	if err != nil {
+		return err
+	}
+
+	format := export.CSV
+	if a.format != "" && a.format != "table" {
+		if format, err = export.ParseFormat(a.format); err != nil {
+			return err
+		}
+	}
+
+	c, err := a.customer()
+	if err != nil {
+		return err
+	}
+	currencies, err := a.mpay.Catalog().Currencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error {
+		ew, err := export.NewWriter(w, format, currencies)
+		if err != nil {
+			return err
+		}
+
+		s := c.ScanTransactionsContext(ctx, f)
+		for s.Scan() {
+			if err := ew.Write(s.Transaction()); err != nil {
+				return err
+			}
+		}
+		if err := s.Err(); err != nil {
+			return err
+		}
+
+		return ew.Flush()
+	}
+
+	if *output == "" {
+		return write(a.stdout)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,331 @@
+// Command moonpay is the command-line client of the MoonPay API.
+//
+//	moonpay [flags] <command> [arguments]
+//
+// The publishable key, the API address and the customer token are read from
+// flags, the selected profile of the config file or, if there is no profile,
+// environment variables MOONPAY_KEY, MOONPAY_URL, MOONPAY_TOKEN. Profiles keep
+// keys and customer sessions saved by login, tokens are encrypted by the key
+// derived from MOONPAY_SECRET passphrase. Mutating commands with the live key
+// ask for the confirmation unless -yes flag is set.
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/cipher"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/sg3des/moonpay"
+	"golang.org/x/term"
+)
+
+// command is the subcommand of the tool
+type command struct {
+	usage string
+	run   func(a *app, ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"currencies": {"currencies                      list supported currencies", currencies},
+	"countries":  {"countries                       list supported countries", countries},
+	"price":      {"price <crypto>... [-fiat eur]    exchange rates of cryptocurrencies", price},
+	"ip":         {"ip                              check the IP address", ipAddress},
+	"login":      {"login <email>                   authenticate the customer by the email code", login},
+	"customer":   {"customer info|limits|update      customer profile", customer},
+	"cards":      {"cards list|delete <id>          customer cards", cards},
+	"tx":         {"tx list|get <id>|create|export  customer transactions", transactions},
+	"profile":    {"profile list|set|use|sessions  named profiles and saved sessions", profiles},
+}
+
+// app is the state of the command run
+type app struct {
+	stdin  *bufio.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	// readPassword reads the line without the echo if stdin is a terminal
+	readPassword func() ([]byte, error)
+
+	// common flags
+	format     string
+	configPath string
+	profile    string
+	key        string
+	url        string
+	token      string
+	yes        bool
+
+	cfg  config
+	name string
+
+	// prof is the selected profile with environment variables and flags
+	// applied, it is not saved
+	prof profile
+
+	// customerToken is the token of the -token flag or MOONPAY_TOKEN
+	customerToken string
+
+	// aead is the cipher of the tokens derived with the salt
+	aead cipher.AEAD
+	salt []byte
+
+	mpay *moonpay.Moonpay
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{
+		stdin:  bufio.NewReader(os.Stdin),
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		getenv: os.Getenv,
+	}
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		a.readPassword = func() ([]byte, error) { return term.ReadPassword(fd) }
+	}
+
+	if err := a.run(ctx, os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "moonpay:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	fs := a.flags("moonpay")
+	fs.Usage = a.usage
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		a.usage()
+		return flag.ErrHelp
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		a.usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	return cmd.run(a, ctx, args[1:])
+}
+
+func (a *app) usage() {
+	fmt.Fprintln(a.stderr, "Usage: moonpay [flags] <command> [arguments]")
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(a.stderr, "  "+commands[name].usage)
+	}
+
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Flags:")
+	a.flags("moonpay").PrintDefaults()
+}
+
+// flags returns the flag set of the command with the common flags
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+
+	fs.StringVar(&a.format, "format", a.format, "output format: table, json or csv, tx export writes csv, jsonl or ofx")
+	fs.StringVar(&a.configPath, "config", a.configPath, "config file, default is $MOONPAY_CONFIG or moonpay/config.json in the user config directory")
+	fs.StringVar(&a.profile, "profile", a.profile, "profile name, default is $MOONPAY_PROFILE or the profile selected by \"profile use\"")
+	fs.StringVar(&a.key, "key", a.key, "publishable key, default is the profile key or $MOONPAY_KEY")
+	fs.StringVar(&a.url, "url", a.url, "API address, default is the profile address or $MOONPAY_URL")
+	fs.StringVar(&a.token, "token", a.token, "customer token, default is the session saved by login or $MOONPAY_TOKEN")
+	fs.BoolVar(&a.yes, "yes", a.yes, "do not ask the confirmation of changes with the live key")
+
+	return fs
+}
+
+// parse parses flags mixed with positional arguments and creates the client
+func (a *app) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional, err := a.parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.setup(); err != nil {
+		return nil, err
+	}
+
+	return positional, nil
+}
+
+// parseArgs parses flags mixed with positional arguments
+func (a *app) parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	return positional, nil
+}
+
+// setup loads the profile and creates the client
+func (a *app) setup() error {
+	cfg, err := loadConfig(a.configFile())
+	if err != nil {
+		return err
+	}
+	a.cfg, a.name = cfg, a.profileName(cfg)
+
+	p, ok := cfg.Profiles[a.name]
+	if ok {
+		a.prof = *p
+	} else if a.name != defaultProfile {
+		return fmt.Errorf("profile %q not found", a.name)
+	}
+
+	// environment variables are used only without the profile, so the key of
+	// another environment does not replace the key of the selected profile
+
+	for _, v := range []struct {
+		field *string
+		env   string
+		flag  string
+	}{
+		{&a.prof.Key, "MOONPAY_KEY", a.key},
+		{&a.prof.URL, "MOONPAY_URL", a.url},
+		{&a.customerToken, "MOONPAY_TOKEN", a.token},
+	} {
+		if env := a.getenv(v.env); env != "" && !ok {
+			*v.field = env
+		}
+		if v.flag != "" {
+			*v.field = v.flag
+		}
+	}
+
+	if a.prof.Key == "" {
+		return errors.New("publishable key is not set, use -key flag, MOONPAY_KEY or the profile")
+	}
+
+	var opts []moonpay.Option
+	if a.prof.URL != "" {
+		opts = append(opts, moonpay.WithBaseURL(a.prof.URL))
+	}
+	a.mpay = moonpay.New(a.prof.Key, opts...)
+
+	return nil
+}
+
+// customer returns the client of the customer by the token or the current
+// session of the profile
+func (a *app) customer() (*moonpay.MoonpayCustomer, error) {
+	if a.customerToken != "" {
+		return a.mpay.Customer(a.customerToken), nil
+	}
+
+	s, ok := a.prof.Sessions[a.prof.Customer]
+	if !ok {
+		return nil, errors.New("customer token is not set, use -token flag, MOONPAY_TOKEN or run login")
+	}
+
+	if len(a.cfg.Salt) == 0 {
+		return nil, errors.New("config has no salt of saved sessions, run login again")
+	}
+	aead, err := a.cipher(&a.cfg)
+	if err != nil {
+		return nil, err
+	}
+	token, err := decrypt(aead, s.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	// the refreshed token replaces the saved one
+	c := a.mpay.Customer(token)
+	c.OnTokenRefresh(func(auth moonpay.CustomerAuth) {
+		if err := a.saveSession(auth); err != nil {
+			fmt.Fprintln(a.stderr, "moonpay: failed save refreshed session:", err)
+		}
+	})
+
+	return c, nil
+}
+
+// errNotConfirmed is returned if the change with the live key is declined
+var errNotConfirmed = errors.New("not confirmed")
+
+// confirm asks the confirmation of the change if the live key is used
+func (a *app) confirm(action string) error {
+	if !isLiveKey(a.prof.Key) || a.yes {
+		return nil
+	}
+
+	answer, err := a.prompt(fmt.Sprintf("%s with the LIVE key of profile %q, type yes to continue: ", action, a.name))
+	if err != nil || answer != "yes" {
+		return errNotConfirmed
+	}
+
+	return nil
+}
+
+// fiat returns the default fiat currency
+func (a *app) fiat() string {
+	if a.prof.Fiat != "" {
+		return a.prof.Fiat
+	}
+	return "eur"
+}
+
+// password reads the secret line from the terminal without the echo, the input
+// which is not a terminal is read as is
+func (a *app) password(msg string) (string, error) {
+	if a.readPassword == nil {
+		return a.prompt(msg)
+	}
+
+	fmt.Fprint(a.stderr, msg)
+	line, err := a.readPassword()
+	fmt.Fprintln(a.stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(line)), nil
+}
+
+// prompt reads the line from the input
+func (a *app) prompt(msg string) (string, error) {
+	fmt.Fprint(a.stderr, msg)
+
+	line, err := a.stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sg3des/moonpay"
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+// cli runs commands against the fake server with the config in the temporary
+// directory
+type cli struct {
+	t      *testing.T
+	srv    *moonpaytest.Server
+	config string
+	env    map[string]string
+
+	// terminal is the passphrase typed in the terminal if it is set
+	terminal *string
+}
+
+func newCLI(t *testing.T, srv *moonpaytest.Server) *cli {
+	return &cli{
+		t:      t,
+		srv:    srv,
+		config: filepath.Join(t.TempDir(), "config.json"),
+		env: map[string]string{
+			"MOONPAY_KEY":    srv.PublishableKey,
+			"MOONPAY_URL":    srv.APIURL(),
+			"MOONPAY_SECRET": "passphrase",
+		},
+	}
+}
+
+// run runs the command and returns the output
+func (c *cli) run(stdin string, args ...string) (string, error) {
+	c.t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	a := &app{
+		stdin:  bufio.NewReader(strings.NewReader(stdin)),
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(key string) string { return c.env[key] },
+	}
+	if c.terminal != nil {
+		a.readPassword = func() ([]byte, error) { return []byte(*c.terminal), nil }
+	}
+
+	err := a.run(context.Background(), append([]string{"-config", c.config}, args...))
+
+	return stdout.String(), err
+}
+
+func TestCurrencies(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	c := newCLI(t, srv)
+
+	out, err := c.run("", "-format", "csv", "currencies")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(moonpaytest.Currencies())+1 {
+		t.Errorf("unexpected number of records %d", len(records))
+	}
+	if records[0][0] != "CODE" {
+		t.Errorf("unexpected header %v", records[0])
+	}
+}
+
+func TestPrice(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	c := newCLI(t, srv)
+
+	out, err := c.run("", "price", "btc", "eth", "-fiat", "eur,gbp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[1], "BTC") || !strings.Contains(lines[1], "EUR") {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+}
+
+func TestLogin(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	c := newCLI(t, srv)
+
+	out, err := c.run(srv.SecurityCode+"\n", "-format", "json", "login", "cli@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var customer moonpay.Customer
+	if err := json.Unmarshal([]byte(out), &customer); err != nil {
+		t.Fatal(err)
+	}
+	if customer.Email != "cli@example.com" {
+		t.Errorf("unexpected customer %+v", customer)
+	}
+	if strings.Contains(out, "eyJ") {
+		t.Errorf("token is printed:\n%s", out)
+	}
+
+	if _, err := c.run("000000\n", "login", "cli@example.com"); err == nil {
+		t.Error("expected error with invalid code")
+	}
+}
+
+func TestCustomer(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	c := newCLI(t, srv)
+	token := srv.Login("customer@example.com")
+
+	if _, err := c.run("", "customer", "info"); err == nil {
+		t.Error("expected error without token")
+	}
+
+	out, err := c.run("", "-token", token, "customer", "update", "-first-name", "John", "-country", "GBR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "John") || !strings.Contains(out, "GBR") {
+		t.Errorf("customer is not updated:\n%s", out)
+	}
+
+	if _, err := c.run("", "-token", token, "customer", "limits"); err != nil {
+		t.Error(err)
+	}
+	if _, err := c.run("", "-token", token, "cards", "list"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	c := newCLI(t, srv)
+	token := srv.Login("tx@example.com")
+
+	m := moonpay.New(srv.PublishableKey, moonpay.WithBaseURL(srv.APIURL()))
+	cardtoken, err := m.CreateToken(moonpay.TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := c.run("", "-token", token, "-format", "json", "tx", "create",
+		"-amount", "100", "-crypto", "btc", "-wallet", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		"-card-token", cardtoken.ID.String(), "-external-id", "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tx moonpay.Transaction
+	if err := json.Unmarshal([]byte(out), &tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.ExternalTransactionID != "order-1" {
+		t.Errorf("unexpected external ID %q", tx.ExternalTransactionID)
+	}
+
+	out, err = c.run("", "-token", token, "tx", "get", tx.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, " btc ") || !strings.Contains(out, " eur ") {
+		t.Errorf("currency codes are not resolved:\n%s", out)
+	}
+
+	out, err = c.run("", "-token", token, "tx", "get", "-external", "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, tx.ID.String()) {
+		t.Errorf("transaction is not found by the external ID:\n%s", out)
+	}
+
+	out, err = c.run("", "-token", token, "-format", "csv", "tx", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out, "\n"); n != 2 {
+		t.Errorf("unexpected number of lines %d:\n%s", n, out)
+	}
+	out, err = c.run("", "-token", token, "-format", "csv", "tx", "list", "-status", "completed,failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out, "\n"); n != 1 {
+		t.Errorf("pending transaction is listed:\n%s", out)
+	}
+
+	if _, err := c.run("", "-token", token, "tx", "list", "-status", "unknown"); err == nil {
+		t.Error("expected error with unknown status")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	live := moonpaytest.NewServer()
+	live.PublishableKey = "pk_live_moonpaytest"
+	defer live.Close()
+
+	c := newCLI(t, srv)
+	c.env = map[string]string{"MOONPAY_SECRET": "passphrase"}
+
+	if _, err := c.run("", "profile", "set", "test", "-key", srv.PublishableKey, "-url", srv.APIURL(), "-fiat", "gbp"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.run("", "profile", "set", "live", "-key", live.PublishableKey, "-url", live.APIURL()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first profile is selected
+	out, err := c.run(srv.SecurityCode+"\n", "-format", "json", "login", "profile@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var customer moonpay.Customer
+	if err := json.Unmarshal([]byte(out), &customer); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(c.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := cfg.Profiles["test"].Sessions[customer.ID.String()]
+	if saved == nil || strings.HasPrefix(saved.Token, "eyJ") || len(cfg.Salt) == 0 {
+		t.Errorf("token is not encrypted %+v", saved)
+	}
+
+	out, err = c.run("", "customer", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "profile@example.com") {
+		t.Errorf("unexpected customer:\n%s", out)
+	}
+
+	// the passphrase is asked if it is not set
+	c.env = nil
+	if _, err := c.run("wrong\n", "customer", "info"); err == nil {
+		t.Error("expected error with wrong passphrase")
+	}
+	if _, err := c.run("passphrase\n", "customer", "info"); err != nil {
+		t.Error(err)
+	}
+
+	// the terminal passphrase is read without the echo instead of the input
+	passphrase := "passphrase"
+	c.terminal = &passphrase
+	if _, err := c.run("wrong\n", "customer", "info"); err != nil {
+		t.Error(err)
+	}
+	c.terminal = nil
+
+	// the selected profile is not overridden by the environment
+	c.env = map[string]string{"MOONPAY_SECRET": "passphrase", "MOONPAY_KEY": live.PublishableKey, "MOONPAY_URL": live.APIURL()}
+	if _, err := c.run("", "customer", "info"); err != nil {
+		t.Error(err)
+	}
+
+	// changes with the live key require the confirmation
+	if _, err := c.run("", "profile", "use", "live"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.run(live.SecurityCode+"\n", "login", "live@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.run("no\n", "customer", "update", "-first-name", "John"); !errors.Is(err, errNotConfirmed) {
+		t.Errorf("expected not confirmed error, got %v", err)
+	}
+	if _, err := c.run("", "customer", "update", "-first-name", "John"); !errors.Is(err, errNotConfirmed) {
+		t.Errorf("expected not confirmed error without input, got %v", err)
+	}
+	if _, err := c.run("yes\n", "customer", "update", "-first-name", "John"); err != nil {
+		t.Error(err)
+	}
+	if _, err := c.run("", "-yes", "customer", "update", "-first-name", "John"); err != nil {
+		t.Error(err)
+	}
+
+	// the test profile keeps its session
+	out, err = c.run("", "-profile", "test", "-format", "csv", "profile", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, customer.ID.String()) {
+		t.Errorf("session is not listed:\n%s", out)
+	}
+
+	if _, err := c.run("", "-profile", "test", "profile", "logout"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.run("", "-profile", "test", "customer", "info"); err == nil {
+		t.Error("expected error after logout")
+	}
+
+	if _, err := c.run("", "-profile", "missing", "currencies"); err == nil {
+		t.Error("expected error with missing profile")
+	}
+}
+
+func TestSessionRefresh(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	srv.TokenTTL = 30 * time.Second
+	c := newCLI(t, srv)
+
+	if _, err := c.run(srv.SecurityCode+"\n", "login", "refresh@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := func() string {
+		cfg, err := loadConfig(c.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := cfg.Profiles[defaultProfile]
+		return p.Sessions[p.Customer].Token
+	}
+	before := token()
+
+	// the token expires soon and it is refreshed by the request
+	if _, err := c.run("", "customer", "info"); err != nil {
+		t.Fatal(err)
+	}
+	if token() == before {
+		t.Error("refreshed token is not saved")
+	}
+	if _, err := c.run("", "customer", "info"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+	c := newCLI(t, srv)
+	token := srv.Login("export@example.com")
+
+	m := moonpay.New(srv.PublishableKey, moonpay.WithBaseURL(srv.APIURL()))
+	cardtoken, err := m.CreateToken(moonpay.TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := m.Customer(token).CreateTransaction(moonpay.TransactionRequest{
+		BaseCurrencyAmount: moonpay.NewAmount(100, 0),
+		BaseCurrencyCode:   "eur",
+		CurrencyCode:       "btc",
+		WalletAddress:      "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		TokenID:            cardtoken.ID.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := c.run("", "-token", token, "tx", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][0] != tx.ID.String() || records[1][5] != "EUR" {
+		t.Errorf("unexpected export:\n%s", out)
+	}
+
+	out, err = c.run("", "-token", token, "-format", "jsonl", "tx", "export", "-from", "2000-01-01", "-to", "2000-02-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("transactions out of the range are exported:\n%s", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "export.ofx")
+	if err := srv.SetTransactionStatus(tx.ID, "completed", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.run("", "-token", token, "-format", "ofx", "tx", "export", "-o", path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("<FITID>"+tx.ID.String()+"</FITID>")) {
+		t.Errorf("transaction is not exported:\n%s", data)
+	}
+}
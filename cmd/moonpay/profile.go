@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sg3des/moonpay"
+)
+
+func profiles(a *app, ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: profile list|set <name>|use <name> [customer]|delete <name>|sessions|logout [customer]")
+	}
+
+	fs := a.flags("profile " + args[0])
+	fiat := fs.String("fiat", "", "default fiat currency of the profile")
+
+	pos, err := a.parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	path := a.configFile()
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	name := a.profileName(cfg)
+	if len(pos) > 0 && args[0] != "sessions" && args[0] != "logout" {
+		name = pos[0]
+	}
+
+	switch args[0] {
+	case "list":
+		return a.printProfiles(cfg)
+
+	case "set":
+		if len(pos) != 1 {
+			return errors.New("usage: profile set <name> [-key key] [-url url] [-fiat code]")
+		}
+
+		p := cfg.Profiles[name]
+		if p == nil {
+			p = new(profile)
+		}
+		if a.key != "" {
+			p.Key = a.key
+		}
+		if a.url != "" {
+			p.URL = a.url
+		}
+		if *fiat != "" {
+			p.Fiat = *fiat
+		}
+		if p.Key == "" {
+			return errors.New("publishable key is not set, use -key flag")
+		}
+
+		if cfg.Profiles == nil {
+			cfg.Profiles = make(map[string]*profile)
+		}
+		cfg.Profiles[name] = p
+		if cfg.Current == "" {
+			cfg.Current = name
+		}
+
+	case "use":
+		if len(pos) < 1 || len(pos) > 2 {
+			return errors.New("usage: profile use <name> [customer]")
+		}
+
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return fmt.Errorf("profile %q not found", name)
+		}
+		if len(pos) == 2 {
+			cid, _, ok := p.session(pos[1])
+			if !ok {
+				return fmt.Errorf("session of %q not found in profile %q", pos[1], name)
+			}
+			p.Customer = cid
+		}
+		cfg.Current = name
+
+	case "delete":
+		if len(pos) != 1 {
+			return errors.New("usage: profile delete <name>")
+		}
+		if _, ok := cfg.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q not found", name)
+		}
+
+		delete(cfg.Profiles, name)
+		if cfg.Current == name {
+			cfg.Current = ""
+		}
+
+	case "sessions":
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return fmt.Errorf("profile %q not found", name)
+		}
+		return a.printSessions(p)
+
+	case "logout":
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return fmt.Errorf("profile %q not found", name)
+		}
+
+		cid := p.Customer
+		if len(pos) > 0 {
+			cid, _, ok = p.session(pos[0])
+			if !ok {
+				return fmt.Errorf("session of %q not found in profile %q", pos[0], name)
+			}
+		}
+		delete(p.Sessions, cid)
+		if p.Customer == cid {
+			p.Customer = ""
+		}
+
+	default:
+		return fmt.Errorf("unknown profile command %q", args[0])
+	}
+
+	if err := saveConfig(path, cfg); err != nil {
+		return err
+	}
+
+	return a.printProfiles(cfg)
+}
+
+func (a *app) printProfiles(cfg config) error {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	current := a.profileName(cfg)
+
+	type item struct {
+		Name     string `json:"name"`
+		Current  bool   `json:"current"`
+		Live     bool   `json:"live"`
+		URL      string `json:"url,omitempty"`
+		Fiat     string `json:"fiat,omitempty"`
+		Sessions int    `json:"sessions"`
+	}
+
+	// keys and tokens are not printed
+	var list []item
+	t := table{header: []string{"NAME", "CURRENT", "MODE", "URL", "FIAT", "SESSIONS"}}
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		list = append(list, item{name, name == current, p.live(), p.URL, p.Fiat, len(p.Sessions)})
+
+		mode := "test"
+		if p.live() {
+			mode = "live"
+		}
+		t.add(name, formatBool(name == current), mode, p.URL, p.Fiat, strconv.Itoa(len(p.Sessions)))
+	}
+
+	return a.print(list, t)
+}
+
+func (a *app) printSessions(p *profile) error {
+	ids := make([]string, 0, len(p.Sessions))
+	for id := range p.Sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	type item struct {
+		Customer   string    `json:"customer"`
+		Current    bool      `json:"current"`
+		Email      string    `json:"email,omitempty"`
+		ExternalID string    `json:"externalId,omitempty"`
+		UpdatedAt  time.Time `json:"updatedAt"`
+	}
+
+	var list []item
+	t := table{header: []string{"CUSTOMER", "CURRENT", "EMAIL", "EXTERNAL ID", "UPDATED"}}
+	for _, id := range ids {
+		s := p.Sessions[id]
+		list = append(list, item{id, id == p.Customer, s.Email, s.ExternalID, s.UpdatedAt})
+		t.add(id, formatBool(id == p.Customer), s.Email, s.ExternalID, formatTime(s.UpdatedAt))
+	}
+
+	return a.print(list, t)
+}
+
+// saveSession encrypts the token of the authenticated customer and saves it
+// as the current session of the profile, the profile is created with the key
+// in use if it does not exist
+func (a *app) saveSession(auth moonpay.CustomerAuth) error {
+	path := a.configFile()
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	aead, err := a.cipher(&cfg)
+	if err != nil {
+		return err
+	}
+	token, err := encrypt(aead, auth.Token)
+	if err != nil {
+		return err
+	}
+
+	p := cfg.Profiles[a.name]
+	if p == nil {
+		p = &profile{Key: a.prof.Key, URL: a.prof.URL, Fiat: a.prof.Fiat}
+		if cfg.Profiles == nil {
+			cfg.Profiles = make(map[string]*profile)
+		}
+		cfg.Profiles[a.name] = p
+	}
+	if p.Sessions == nil {
+		p.Sessions = make(map[string]*session)
+	}
+
+	cid := auth.Customer.ID.String()
+	p.Sessions[cid] = &session{
+		Email:      auth.Customer.Email,
+		ExternalID: auth.Customer.ExternalCustomerID,
+		Token:      token,
+		UpdatedAt:  time.Now().UTC(),
+	}
+	p.Customer = cid
+
+	return saveConfig(path, cfg)
+}
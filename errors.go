@@ -0,0 +1,133 @@
+package moonpay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/imroc/req"
+)
+
+// Kinds of the errors, RespError matches them with errors.Is:
+//
+//	if errors.Is(err, moonpay.ErrLimitExceeded) {...}
+var (
+	ErrUnauthorized  = errors.New("moonpay: unauthorized")
+	ErrValidation    = errors.New("moonpay: validation failed")
+	ErrNotFound      = errors.New("moonpay: not found")
+	ErrRateLimited   = errors.New("moonpay: rate limited")
+	ErrLimitExceeded = errors.New("moonpay: limit exceeded")
+	ErrServer        = errors.New("moonpay: server error")
+)
+
+// RespError is the error response of the API
+type RespError struct {
+	Status     string
+	StatusCode int
+
+	Errors  []FieldError
+	Message string
+	Name    string
+}
+
+// FieldError describes the invalid property of the request
+type FieldError struct {
+	Target      map[string]interface{}
+	Value       interface{}
+	Property    string
+	Constraints map[string]string
+}
+
+func (e *RespError) Error() string {
+	if len(e.Errors) > 0 {
+		var ss []string
+		for _, er := range e.Errors {
+			for _, v := range er.Constraints {
+				ss = append(ss, fmt.Sprint(v))
+			}
+		}
+
+		return strings.Join(ss, "; ")
+	}
+	if e.Message != "" {
+		return e.Message
+	}
+
+	return e.Status
+}
+
+// Kind returns one of the Err* kinds of the error, or nil if the error is not
+// classified
+func (e *RespError) Kind() error {
+	switch code := e.StatusCode; {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrUnauthorized
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= http.StatusInternalServerError:
+		return ErrServer
+	case strings.Contains(strings.ToLower(e.Name), "limit"):
+		return ErrLimitExceeded
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return ErrValidation
+	}
+
+	return nil
+}
+
+// Is reports whether the error is of the target kind
+func (e *RespError) Is(target error) bool {
+	kind := e.Kind()
+	return kind != nil && kind == target
+}
+
+// Field returns messages of the constraints violated by the property
+func (e *RespError) Field(property string) []string {
+	return fieldMessages(e.Errors, property)
+}
+
+// Fields returns violated constraints messages grouped by the property
+func (e *RespError) Fields() map[string][]string {
+	return fieldsMessages(e.Errors)
+}
+
+func fieldMessages(errs []FieldError, property string) []string {
+	var msgs []string
+	for _, fe := range errs {
+		if fe.Property == property {
+			for _, msg := range fe.Constraints {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+
+	return msgs
+}
+
+func fieldsMessages(errs []FieldError) map[string][]string {
+	fields := make(map[string][]string)
+	for _, fe := range errs {
+		for _, msg := range fe.Constraints {
+			fields[fe.Property] = append(fields[fe.Property], msg)
+		}
+	}
+
+	return fields
+}
+
+func (m *Moonpay) handleError(resp *req.Resp, err error) error {
+	if err != nil {
+		return err
+	}
+	if r := resp.Response(); r.StatusCode >= 400 {
+		err := &RespError{Status: r.Status, StatusCode: r.StatusCode}
+		resp.ToJSON(err)
+
+		return err
+	}
+
+	return nil
+}
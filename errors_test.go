@@ -0,0 +1,68 @@
+package moonpay
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+func TestRespErrorKind(t *testing.T) {
+	cases := []struct {
+		err  *RespError
+		kind error
+	}{
+		{&RespError{StatusCode: 401}, ErrUnauthorized},
+		{&RespError{StatusCode: 403}, ErrUnauthorized},
+		{&RespError{StatusCode: 404}, ErrNotFound},
+		{&RespError{StatusCode: 429}, ErrRateLimited},
+		{&RespError{StatusCode: 502}, ErrServer},
+		{&RespError{StatusCode: 400, Name: "LimitExceededError"}, ErrLimitExceeded},
+		{&RespError{StatusCode: 400, Name: "BadRequestError"}, ErrValidation},
+		{&RespError{StatusCode: 409}, nil},
+	}
+
+	for _, c := range cases {
+		if kind := c.err.Kind(); kind != c.kind {
+			t.Errorf("%d %s: expected %v, got %v", c.err.StatusCode, c.err.Name, c.kind, kind)
+		}
+		if c.kind != nil && !errors.Is(c.err, c.kind) {
+			t.Errorf("%d %s: errors.Is does not match %v", c.err.StatusCode, c.err.Name, c.kind)
+		}
+	}
+}
+
+func TestRespErrorFields(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	m := New(srv.PublishableKey, WithBaseURL(srv.APIURL()))
+	customer := m.Customer(srv.Login("errors@example.com"))
+
+	_, err := customer.Update(CustomerFields{Email: "invalid", DateOfBirth: "yesterday"})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+
+	var respErr *RespError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("unexpected error type %T", err)
+	}
+	if msgs := respErr.Field("email"); len(msgs) != 1 || msgs[0] != "email must be an email" {
+		t.Errorf("unexpected email messages: %v", msgs)
+	}
+	if len(respErr.Fields()) != 2 {
+		t.Errorf("unexpected fields: %v", respErr.Fields())
+	}
+
+	_, err = customer.Transaction(uuid.New())
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	_, err = m.Customer("invalid").Info()
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+// Package export writes transactions of customers to CSV, JSON Lines and OFX
+// files. Currency IDs of transactions are resolved to codes by the currency
+// list.
+//
+//	currencies, _ := mpay.Currencies()
+//	txs, _ := customer.Transactions()
+//	err := export.Write(os.Stdout, export.CSV, txs, currencies)
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sg3des/moonpay"
+)
+
+// Format is the format of the export
+type Format string
+
+// Supported formats
+const (
+	CSV   Format = "csv"
+	JSONL Format = "jsonl"
+	OFX   Format = "ofx"
+)
+
+// ErrFormat is returned if the format is not supported
+var ErrFormat = errors.New("export: unsupported format")
+
+// ParseFormat returns the format by the name, the case is ignored
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, JSONL, OFX:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("%w %q, use csv, jsonl or ofx", ErrFormat, s)
+}
+
+// Record is the transaction with the currency codes
+type Record struct {
+	ID            uuid.UUID                 `json:"id"`
+	CreatedAt     time.Time                 `json:"createdAt"`
+	UpdatedAt     time.Time                 `json:"updatedAt"`
+	Status        moonpay.TransactionStatus `json:"status"`
+	FailureReason moonpay.FailureReason     `json:"failureReason,omitempty"`
+
+	BaseCurrency       string         `json:"baseCurrency"`
+	BaseCurrencyAmount moonpay.Amount `json:"baseCurrencyAmount"`
+	FeeAmount          moonpay.Amount `json:"feeAmount"`
+	ExtraFeeAmount     moonpay.Amount `json:"extraFeeAmount"`
+	AreFeesIncluded    bool           `json:"areFeesIncluded"`
+
+	Currency            string         `json:"currency"`
+	QuoteCurrencyAmount moonpay.Amount `json:"quoteCurrencyAmount"`
+
+	// rates of the cryptocurrency at the moment of the transaction
+	EURrate moonpay.Amount `json:"eurRate"`
+	USDrate moonpay.Amount `json:"usdRate"`
+	GBPrate moonpay.Amount `json:"gbpRate"`
+
+	WalletAddress         string    `json:"walletAddress"`
+	WalletAddressTag      string    `json:"walletAddressTag,omitempty"`
+	CryptoTransactionID   string    `json:"cryptoTransactionId,omitempty"`
+	ExternalTransactionID string    `json:"externalTransactionId,omitempty"`
+	CustomerID            uuid.UUID `json:"customerId"`
+	CardID                uuid.UUID `json:"cardId"`
+}
+
+// Total returns the amount charged from the customer including fees, the base
+// amount is the total if fees are included in it
+func (r Record) Total() moonpay.Amount {
+	if r.AreFeesIncluded {
+		return r.BaseCurrencyAmount
+	}
+	return r.BaseCurrencyAmount.Add(r.FeeAmount).Add(r.ExtraFeeAmount)
+}
+
+var header = []string{
+	"id", "created_at", "updated_at", "status", "failure_reason",
+	"base_currency", "base_currency_amount", "fee_amount", "extra_fee_amount", "fees_included",
+	"currency", "quote_currency_amount",
+	"eur_rate", "usd_rate", "gbp_rate",
+	"wallet_address", "wallet_address_tag", "crypto_transaction_id", "external_transaction_id",
+	"customer_id", "card_id",
+}
+
+func (r Record) row() []string {
+	return []string{
+		r.ID.String(), formatTime(r.CreatedAt), formatTime(r.UpdatedAt), string(r.Status), string(r.FailureReason),
+		r.BaseCurrency, r.BaseCurrencyAmount.String(), r.FeeAmount.String(), r.ExtraFeeAmount.String(), fmt.Sprint(r.AreFeesIncluded),
+		r.Currency, r.QuoteCurrencyAmount.String(),
+		r.EURrate.String(), r.USDrate.String(), r.GBPrate.String(),
+		r.WalletAddress, r.WalletAddressTag, r.CryptoTransactionID, r.ExternalTransactionID,
+		r.CustomerID.String(), r.CardID.String(),
+	}
+}
+
+// Writer writes transactions in the format. CSV and JSON Lines are written as
+// transactions come, OFX is written by Flush.
+type Writer struct {
+	w      io.Writer
+	format Format
+	codes  map[uuid.UUID]string
+
+	csv    *csv.Writer
+	header bool
+	ofx    []Record
+}
+
+// NewWriter returns the writer, the currencies are used to resolve codes, the
+// ID is written if the currency is not found
+func NewWriter(w io.Writer, format Format, currencies []moonpay.Currency) (*Writer, error) {
+	if _, err := ParseFormat(string(format)); err != nil {
+		return nil, err
+	}
+
+	codes := make(map[uuid.UUID]string, len(currencies))
+	for _, c := range currencies {
+		codes[c.ID] = strings.ToUpper(c.Code)
+	}
+
+	ew := &Writer{w: w, format: format, codes: codes}
+	if format == CSV {
+		ew.csv = csv.NewWriter(w)
+	}
+
+	return ew, nil
+}
+
+// Record returns the transaction with the resolved currency codes
+func (w *Writer) Record(tx moonpay.Transaction) Record {
+	return Record{
+		ID:            tx.ID,
+		CreatedAt:     tx.CreatedAt,
+		UpdatedAt:     tx.UpdatedAt,
+		Status:        tx.Status,
+		FailureReason: tx.FailureReason,
+
+		BaseCurrency:       w.code(tx.BaseCurrencyID),
+		BaseCurrencyAmount: tx.BaseCurrencyAmount,
+		FeeAmount:          tx.FeeAmount,
+		ExtraFeeAmount:     tx.ExtraFeeAmount,
+		AreFeesIncluded:    tx.AreFeesIncluded,
+
+		Currency:            w.code(tx.CurrencyID),
+		QuoteCurrencyAmount: tx.QuoteCurrencyAmount,
+
+		EURrate: tx.EURrate,
+		USDrate: tx.USDrate,
+		GBPrate: tx.GBPrate,
+
+		WalletAddress:         tx.WalletAddress,
+		WalletAddressTag:      tx.WalletAddressTag,
+		CryptoTransactionID:   tx.CryptoTransactionId,
+		ExternalTransactionID: tx.ExternalTransactionID,
+		CustomerID:            tx.CustomerID,
+		CardID:                tx.CardID,
+	}
+}
+
+// Write writes the transaction
+func (w *Writer) Write(tx moonpay.Transaction) error {
+	r := w.Record(tx)
+
+	switch w.format {
+	case CSV:
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+		return w.csv.Write(r.row())
+
+	case JSONL:
+		return json.NewEncoder(w.w).Encode(r)
+	}
+
+	w.ofx = append(w.ofx, r)
+	return nil
+}
+
+// Flush writes the buffered data, the CSV header is written even if there are
+// no transactions
+func (w *Writer) Flush() error {
+	switch w.format {
+	case CSV:
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+		w.csv.Flush()
+		return w.csv.Error()
+
+	case OFX:
+		records := w.ofx
+		w.ofx = nil
+		return writeOFX(w.w, records, time.Now())
+	}
+
+	return nil
+}
+
+// Write writes transactions to w in the format
+func Write(w io.Writer, format Format, txs []moonpay.Transaction, currencies []moonpay.Currency) error {
+	ew, err := NewWriter(w, format, currencies)
+	if err != nil {
+		return err
+	}
+
+	for _, tx := range txs {
+		if err := ew.Write(tx); err != nil {
+			return err
+		}
+	}
+
+	return ew.Flush()
+}
+
+func (w *Writer) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+
+	return w.csv.Write(header)
+}
+
+func (w *Writer) code(id uuid.UUID) string {
+	if code, ok := w.codes[id]; ok {
+		return code
+	}
+	return id.String()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
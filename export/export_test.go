@@ -0,0 +1,182 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sg3des/moonpay"
+)
+
+var (
+	eur = moonpay.Currency{ID: uuid.New(), Code: "eur", Type: "fiat", Precision: 2}
+	gbp = moonpay.Currency{ID: uuid.New(), Code: "gbp", Type: "fiat", Precision: 2}
+	btc = moonpay.Currency{ID: uuid.New(), Code: "btc", Type: "crypto", Precision: 8}
+
+	currencies = []moonpay.Currency{eur, gbp, btc}
+	customerID = uuid.New()
+	created    = time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+)
+
+func transaction(base moonpay.Currency, amount string, status moonpay.TransactionStatus) moonpay.Transaction {
+	return moonpay.Transaction{
+		ID:                  uuid.New(),
+		CreatedAt:           created,
+		UpdatedAt:           created.Add(time.Minute),
+		Status:              status,
+		BaseCurrencyID:      base.ID,
+		BaseCurrencyAmount:  moonpay.MustParseAmount(amount),
+		FeeAmount:           moonpay.MustParseAmount("4.99"),
+		ExtraFeeAmount:      moonpay.MustParseAmount("1.01"),
+		CurrencyID:          btc.ID,
+		QuoteCurrencyAmount: moonpay.MustParseAmount("0.01173"),
+		EURrate:             moonpay.MustParseAmount("8525.5"),
+		USDrate:             moonpay.MustParseAmount("9320.12"),
+		GBPrate:             moonpay.MustParseAmount("7301.07"),
+		WalletAddress:       "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		CustomerID:          customerID,
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("OFX"); err != nil || f != OFX {
+		t.Errorf("unexpected format %q: %v", f, err)
+	}
+	if _, err := ParseFormat("xls"); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	unknown := transaction(eur, "100", moonpay.StatusCompleted)
+	unknown.CurrencyID = uuid.New()
+
+	txs := []moonpay.Transaction{transaction(eur, "100", moonpay.StatusCompleted), unknown}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, CSV, txs, currencies); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("unexpected number of records %d", len(records))
+	}
+
+	row := make(map[string]string)
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+	for name, expected := range map[string]string{
+		"base_currency":         "EUR",
+		"currency":              "BTC",
+		"base_currency_amount":  "100",
+		"fee_amount":            "4.99",
+		"quote_currency_amount": "0.01173",
+		"eur_rate":              "8525.5",
+		"gbp_rate":              "7301.07",
+		"created_at":            "2026-09-01T10:00:00Z",
+	} {
+		if row[name] != expected {
+			t.Errorf("unexpected %s %q, expected %q", name, row[name], expected)
+		}
+	}
+
+	if currency := records[2][10]; currency != unknown.CurrencyID.String() {
+		t.Errorf("unknown currency is written as %q", currency)
+	}
+}
+
+func TestCSVEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, CSV, nil, currencies); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "id,created_at,") {
+		t.Errorf("header is not written: %q", buf.String())
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	txs := []moonpay.Transaction{
+		transaction(eur, "100", moonpay.StatusCompleted),
+		transaction(gbp, "50.5", moonpay.StatusFailed),
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, JSONL, txs, currencies); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("unexpected number of records %d", len(records))
+	}
+	if r := records[1]; r.BaseCurrency != "GBP" || !r.BaseCurrencyAmount.Equal(moonpay.MustParseAmount("50.5")) || r.Status != moonpay.StatusFailed {
+		t.Errorf("unexpected record %+v", r)
+	}
+}
+
+func TestOFX(t *testing.T) {
+	included := transaction(eur, "20", moonpay.StatusCompleted)
+	included.AreFeesIncluded = true
+
+	txs := []moonpay.Transaction{
+		transaction(eur, "100", moonpay.StatusCompleted),
+		included,
+		transaction(gbp, "50", moonpay.StatusCompleted),
+		transaction(eur, "30", moonpay.StatusFailed),
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, OFX, txs, currencies); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<?OFX OFXHEADER="200"`) {
+		t.Error("OFX header is not written")
+	}
+
+	var doc ofx
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Statements) != 2 {
+		t.Fatalf("unexpected number of statements %d", len(doc.Statements))
+	}
+
+	st := doc.Statements[0]
+	if st.Currency != "EUR" || st.Account.AcctID != customerID.String() {
+		t.Errorf("unexpected statement %s %s", st.Currency, st.Account.AcctID)
+	}
+	if len(st.Transactions) != 2 {
+		t.Fatalf("failed transactions are exported")
+	}
+	if tx := st.Transactions[0]; tx.Amount != "-106.00" || tx.FITID != txs[0].ID.String() || tx.User != "20260901100000.000[0:GMT]" {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	if tx := st.Transactions[1]; tx.Amount != "-20" {
+		t.Errorf("fees are added to the amount including them: %s", tx.Amount)
+	}
+	if st.Balance != "-126.00" {
+		t.Errorf("unexpected balance %s", st.Balance)
+	}
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/sg3des/moonpay"
+)
+
+// ofxHeader is the header of OFX 2.1.1
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+type ofx struct {
+	XMLName xml.Name `xml:"OFX"`
+	Signon  struct {
+		Status   ofxStatus `xml:"SONRS>STATUS"`
+		Server   string    `xml:"SONRS>DTSERVER"`
+		Language string    `xml:"SONRS>LANGUAGE"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Statements []ofxStatement `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxStatement struct {
+	TrnUID   int       `xml:"TRNUID"`
+	Status   ofxStatus `xml:"STATUS"`
+	Currency string    `xml:"STMTRS>CURDEF"`
+	Account  struct {
+		BankID string `xml:"BANKID"`
+		AcctID string `xml:"ACCTID"`
+		Type   string `xml:"ACCTTYPE"`
+	} `xml:"STMTRS>BANKACCTFROM"`
+	Start        string           `xml:"STMTRS>BANKTRANLIST>DTSTART"`
+	End          string           `xml:"STMTRS>BANKTRANLIST>DTEND"`
+	Transactions []ofxTransaction `xml:"STMTRS>BANKTRANLIST>STMTTRN"`
+	Balance      string           `xml:"STMTRS>LEDGERBAL>BALAMT"`
+	BalanceAsOf  string           `xml:"STMTRS>LEDGERBAL>DTASOF"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	User   string `xml:"DTUSER"`
+	Amount string `xml:"TRNAMT"`
+	FITID  string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+	Memo   string `xml:"MEMO"`
+}
+
+// writeOFX writes completed transactions as the bank statement, there is the
+// statement for each customer and fiat currency. Amounts are the totals charged
+// including fees, they are negative as the customer pays.
+func writeOFX(w io.Writer, records []Record, now time.Time) error {
+	type account struct{ customer, currency string }
+
+	groups := make(map[account][]Record)
+	var accounts []account
+	for _, r := range records {
+		if r.Status != moonpay.StatusCompleted {
+			continue
+		}
+
+		acc := account{r.CustomerID.String(), r.BaseCurrency}
+		if _, ok := groups[acc]; !ok {
+			accounts = append(accounts, acc)
+		}
+		groups[acc] = append(groups[acc], r)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].customer != accounts[j].customer {
+			return accounts[i].customer < accounts[j].customer
+		}
+		return accounts[i].currency < accounts[j].currency
+	})
+
+	var doc ofx
+	doc.Signon.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	doc.Signon.Server = ofxTime(now)
+	doc.Signon.Language = "ENG"
+
+	for i, acc := range accounts {
+		list := groups[acc]
+		sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+
+		st := ofxStatement{
+			TrnUID:   i + 1,
+			Status:   ofxStatus{Code: 0, Severity: "INFO"},
+			Currency: acc.currency,
+		}
+		st.Account.BankID = "MOONPAY"
+		st.Account.AcctID = acc.customer
+		st.Account.Type = "CHECKING"
+
+		var balance moonpay.Amount
+		end := list[0].UpdatedAt
+		for _, r := range list {
+			balance = balance.Sub(r.Total())
+			if r.UpdatedAt.After(end) {
+				end = r.UpdatedAt
+			}
+			st.Transactions = append(st.Transactions, ofxTransaction{
+				Type:   "DEBIT",
+				Posted: ofxTime(r.UpdatedAt),
+				User:   ofxTime(r.CreatedAt),
+				Amount: r.Total().Neg().String(),
+				FITID:  r.ID.String(),
+				Name:   "MoonPay " + r.Currency,
+				Memo:   fmt.Sprintf("%s %s, fee %s %s", r.QuoteCurrencyAmount, r.Currency, r.FeeAmount.Add(r.ExtraFeeAmount), r.BaseCurrency),
+			})
+		}
+
+		st.Start = ofxTime(list[0].CreatedAt)
+		st.End = ofxTime(end)
+		st.Balance = balance.String()
+		st.BalanceAsOf = st.End
+
+		doc.Statements = append(doc.Statements, st)
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ofxTime formats the time as OFX datetime in UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
@@ -0,0 +1,51 @@
+// Command snapshotgen writes the catalog snapshot embedded in the package.
+// The data is loaded from the live API by the key of MOONPAY_KEY.
+//
+//	MOONPAY_KEY=pk_live_... go generate github.com/sg3des/moonpay
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sg3des/moonpay"
+)
+
+func main() {
+	output := flag.String("o", "snapshot/catalog.json", "output file")
+	flag.Parse()
+
+	key := os.Getenv("MOONPAY_KEY")
+	if key == "" {
+		log.Fatal("MOONPAY_KEY is not set, the snapshot must be generated from the live API")
+	}
+	mpay := moonpay.New(key)
+
+	currencies, err := mpay.Currencies()
+	if err != nil {
+		log.Fatal(err)
+	}
+	countries, err := mpay.Countries()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := json.MarshalIndent(moonpay.Snapshot{
+		Version:     moonpay.SnapshotVersion,
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+		Source:      "https://api.moonpay.io/v3",
+		Currencies:  currencies,
+		Countries:   countries,
+	}, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
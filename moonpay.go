@@ -1,11 +1,16 @@
 package moonpay
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/goware/urlx"
@@ -17,66 +22,121 @@ const apiAddr = "https://api.moonpay.io/v3"
 type Moonpay struct {
 	u      url.URL
 	pubkey string
+	secret string
+
+	buyWidget  string
+	sellWidget string
+
+	r         *req.Req
+	client    *http.Client
+	transport http.RoundTripper
+	timeout   time.Duration
+	userAgent string
+	logger    Logger
+	sessions  SessionStore
+	retry     RetryPolicy
+	limits    map[RateGroup]*bucket
+
+	catalog    *Catalog
+	catalogTTL time.Duration
+
+	// err is an error of the options, it is returned on each request
+	err error
 }
 
-func New(pubkey string) *Moonpay {
+// New creates the client with specified publishable key, the behaviour may
+// be configured by options
+func New(pubkey string, opts ...Option) *Moonpay {
 	u, _ := urlx.ParseWithDefaultScheme(apiAddr, "https")
 
-	return &Moonpay{
-		pubkey: pubkey,
-		u:      *u,
+	m := &Moonpay{
+		pubkey:     pubkey,
+		u:          *u,
+		userAgent:  defaultUserAgent,
+		retry:      DefaultRetryPolicy,
+		catalogTTL: DefaultCatalogTTL,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.catalog = NewCatalog(m, m.catalogTTL)
+
+	m.r = req.New()
+	m.r.SetClient(m.httpClient())
+
+	return m
 }
 
 func (m *Moonpay) url(p string, a ...interface{}) string {
+	args := make([]interface{}, len(a))
+	for i, v := range a {
+		args[i] = url.PathEscape(fmt.Sprint(v))
+	}
+
 	u := m.u
-	u.Path = path.Join("/v3/", fmt.Sprintf(p, a...))
+	u.RawPath = path.Join("/", m.u.EscapedPath(), fmt.Sprintf(p, args...))
+	u.Path, _ = url.PathUnescape(u.RawPath)
 	return u.String()
 }
 
-type RespError struct {
-	Status string
+// do executes request with the context attached, ctx cancellation and deadline
+// are propagated down to the transport. Failed requests are retried according
+// to the retry policy.
+func (m *Moonpay) do(ctx context.Context, method, url string, v ...interface{}) (*req.Resp, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
-	Errors []struct {
-		Target      map[string]interface{}
-		Value       string
-		Propery     string
-		Constraints map[string]interface{}
+	v = append(v, ctx, req.Header{"User-Agent": m.userAgent})
+	// safe requests made with the keyed context, e.g. the validation of the
+	// transaction, must not reuse the key of the change
+	if key := IdempotencyKey(ctx); key != "" && method != "GET" && method != "HEAD" {
+		v = append(v, req.Header{IdempotencyKeyHeader: key})
 	}
-	Message string
-	Name    string
-}
 
-func (e *RespError) Error() string {
-	if len(e.Errors) > 0 {
-		var ss []string
-		for _, er := range e.Errors {
-			for _, v := range er.Constraints {
-				ss = append(ss, fmt.Sprint(v))
+	limiter := m.limiter(url)
+
+	for attempt := 1; ; attempt++ {
+		if limiter != nil {
+			if err := limiter.wait(ctx); err != nil {
+				return nil, err
 			}
 		}
 
-		return strings.Join(ss, "; ")
-	}
-	if e.Message != "" {
-		return e.Message
-	}
+		resp, err := m.r.Do(method, url, v...)
 
-	return e.Status
-}
+		var r *http.Response
+		if err == nil {
+			r = resp.Response()
+			m.logf("moonpay: %s %s: %s", method, r.Request.URL.Path, r.Status)
 
-func (m *Moonpay) handleError(resp *req.Resp, err error) error {
-	if err != nil {
-		return err
-	}
-	if r := resp.Response(); r.StatusCode >= 400 {
-		err := &RespError{Status: r.Status}
-		resp.ToJSON(err)
+			if limiter != nil {
+				limiter.update(r, time.Now())
+			}
+		}
 
-		return err
-	}
+		herr := m.handleError(resp, err)
+		if herr == nil {
+			return resp, nil
+		}
 
-	return nil
+		if attempt >= m.retry.MaxAttempts || !replayable(v) || !m.retry.retryable(ctx, method, r, err) {
+			return nil, herr
+		}
+
+		d, ok := m.retry.backoff(attempt, r)
+		if !ok {
+			return nil, herr
+		}
+		m.logf("moonpay: %s: attempt %d failed, retry in %s: %v", method, attempt, d, herr)
+		if err := sleep(ctx, d); err != nil {
+			return nil, herr
+		}
+	}
 }
 
 //
@@ -85,9 +145,14 @@ func (m *Moonpay) handleError(resp *req.Resp, err error) error {
 
 // Currencies returns a list of all currencies supported by MoonPay.
 // https://www.moonpay.io/api_reference/v3#list_currencies
-func (m *Moonpay) Currencies() (list []Currency, err error) {
-	resp, err := req.Get(m.url("/currencies"))
-	if err := m.handleError(resp, err); err != nil {
+func (m *Moonpay) Currencies() ([]Currency, error) {
+	return m.CurrenciesContext(context.Background())
+}
+
+// CurrenciesContext is like Currencies but with the context.
+func (m *Moonpay) CurrenciesContext(ctx context.Context) (list []Currency, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/currencies"))
+	if err != nil {
 		return nil, err
 	}
 
@@ -98,12 +163,17 @@ func (m *Moonpay) Currencies() (list []Currency, err error) {
 // CurrencyPrice get the current exchange rates of a currency. Supply the
 // currency code, and MoonPay will return the corresponding exchange rates.
 // https://www.moonpay.io/api_reference/v3#get_currency_exchange_rate
-func (m *Moonpay) CurrencyPrice(crypto string) (prices map[string]float64, err error) {
-	resp, err := req.Get(
+func (m *Moonpay) CurrencyPrice(crypto string) (map[string]Amount, error) {
+	return m.CurrencyPriceContext(context.Background(), crypto)
+}
+
+// CurrencyPriceContext is like CurrencyPrice but with the context.
+func (m *Moonpay) CurrencyPriceContext(ctx context.Context, crypto string) (prices map[string]Amount, err error) {
+	resp, err := m.do(ctx, "GET",
 		m.url("/currencies/%s/price", strings.ToLower(crypto)),
 		req.QueryParam{"apiKey": m.pubkey},
 	)
-	if err := m.handleError(resp, err); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
@@ -116,8 +186,13 @@ func (m *Moonpay) CurrencyPrice(crypto string) (prices map[string]float64, err e
 // currencies you are interested in, and MoonPay will return the relevant
 // exchange rates.
 // https://www.moonpay.io/api_reference/v3#get_multiple_exchange_rates
-func (m *Moonpay) CurrenciesPrice(crypto, fiat []string) (prices map[string]map[string]float64, err error) {
-	resp, err := req.Get(
+func (m *Moonpay) CurrenciesPrice(crypto, fiat []string) (map[string]map[string]Amount, error) {
+	return m.CurrenciesPriceContext(context.Background(), crypto, fiat)
+}
+
+// CurrenciesPriceContext is like CurrenciesPrice but with the context.
+func (m *Moonpay) CurrenciesPriceContext(ctx context.Context, crypto, fiat []string) (prices map[string]map[string]Amount, err error) {
+	resp, err := m.do(ctx, "GET",
 		m.url("/currencies/price"),
 		req.QueryParam{
 			"apiKey":           m.pubkey,
@@ -125,7 +200,7 @@ func (m *Moonpay) CurrenciesPrice(crypto, fiat []string) (prices map[string]map[
 			"fiatCurrencies":   strings.Join(fiat, ","),
 		},
 	)
-	if err := m.handleError(resp, err); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
@@ -133,8 +208,49 @@ func (m *Moonpay) CurrenciesPrice(crypto, fiat []string) (prices map[string]map[
 	return
 }
 
+// CurrencyQuote returns the quote for spending of fiatAmount on the crypto, the
+// fees are included in the fiatAmount. Fee is the extra fee percentage.
 // https://www.moonpay.io/api_reference/v3#get_currency_quote
-// func (m *Moonpay) CurrencyQuote(crypto, fiat string, fiatAmount, fee float64)
+func (m *Moonpay) CurrencyQuote(crypto, fiat string, fiatAmount, fee Amount) (Quote, error) {
+	return m.CurrencyQuoteContext(context.Background(), crypto, fiat, fiatAmount, fee)
+}
+
+// CurrencyQuoteContext is like CurrencyQuote but with the context.
+func (m *Moonpay) CurrencyQuoteContext(ctx context.Context, crypto, fiat string, fiatAmount, fee Amount) (Quote, error) {
+	return m.quote(ctx, crypto, req.QueryParam{
+		"baseCurrencyCode":   strings.ToLower(fiat),
+		"baseCurrencyAmount": fiatAmount,
+		"extraFeePercentage": fee,
+		"areFeesIncluded":    true,
+	})
+}
+
+// CurrencyQuoteReceive returns the quote for receiving of cryptoAmount, the
+// fees are added to the fiat amount. Fee is the extra fee percentage.
+func (m *Moonpay) CurrencyQuoteReceive(crypto, fiat string, cryptoAmount, fee Amount) (Quote, error) {
+	return m.CurrencyQuoteReceiveContext(context.Background(), crypto, fiat, cryptoAmount, fee)
+}
+
+// CurrencyQuoteReceiveContext is like CurrencyQuoteReceive but with the context.
+func (m *Moonpay) CurrencyQuoteReceiveContext(ctx context.Context, crypto, fiat string, cryptoAmount, fee Amount) (Quote, error) {
+	return m.quote(ctx, crypto, req.QueryParam{
+		"baseCurrencyCode":    strings.ToLower(fiat),
+		"quoteCurrencyAmount": cryptoAmount,
+		"extraFeePercentage":  fee,
+	})
+}
+
+func (m *Moonpay) quote(ctx context.Context, crypto string, params req.QueryParam) (q Quote, err error) {
+	params["apiKey"] = m.pubkey
+
+	resp, err := m.do(ctx, "GET", m.url("/currencies/%s/quote", strings.ToLower(crypto)), params)
+	if err != nil {
+		return q, err
+	}
+
+	err = resp.ToJSON(&q)
+	return
+}
 
 //
 //
@@ -142,9 +258,14 @@ func (m *Moonpay) CurrenciesPrice(crypto, fiat []string) (prices map[string]map[
 
 // Countries returnes a list of all countries supported by MoonPay
 // https://www.moonpay.io/api_reference/v3#list_countries
-func (m *Moonpay) Countries() (countries []Country, err error) {
-	resp, err := req.Get(m.url("/countries"))
-	if err := m.handleError(resp, err); err != nil {
+func (m *Moonpay) Countries() ([]Country, error) {
+	return m.CountriesContext(context.Background())
+}
+
+// CountriesContext is like Countries but with the context.
+func (m *Moonpay) CountriesContext(ctx context.Context) (countries []Country, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/countries"))
+	if err != nil {
 		return nil, err
 	}
 
@@ -154,9 +275,14 @@ func (m *Moonpay) Countries() (countries []Country, err error) {
 
 // IPaddress returns information about an IP address
 // https://www.moonpay.io/api_reference/v3#check_ip_address
-func (m *Moonpay) IPaddress() (ip IPaddress, err error) {
-	resp, err := req.Get(m.url("/ip_address"), req.QueryParam{"apiKey": m.pubkey})
-	if err := m.handleError(resp, err); err != nil {
+func (m *Moonpay) IPaddress() (IPaddress, error) {
+	return m.IPaddressContext(context.Background())
+}
+
+// IPaddressContext is like IPaddress but with the context.
+func (m *Moonpay) IPaddressContext(ctx context.Context) (ip IPaddress, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/ip_address"), req.QueryParam{"apiKey": m.pubkey})
+	if err != nil {
 		return ip, err
 	}
 
@@ -178,12 +304,17 @@ type email_login_data struct {
 // is first step for authentication process
 // https://www.moonpay.io/api_reference/v3#authenticate_customer_email
 func (m *Moonpay) SecurityCode(email string) (bool, error) {
-	resp, err := req.Post(
+	return m.SecurityCodeContext(context.Background(), email)
+}
+
+// SecurityCodeContext is like SecurityCode but with the context.
+func (m *Moonpay) SecurityCodeContext(ctx context.Context, email string) (bool, error) {
+	resp, err := m.do(ctx, "POST",
 		m.url("/customers/email_login"),
 		req.QueryParam{"apiKey": m.pubkey},
 		req.BodyJSON(email_login_data{Email: email}),
 	)
-	if err := m.handleError(resp, err); err != nil {
+	if err != nil {
 		return false, err
 	}
 
@@ -195,15 +326,20 @@ func (m *Moonpay) SecurityCode(email string) (bool, error) {
 // ConfirmRegistration validates the email and authenticates the customer
 // is second step for authentication process
 // https://www.moonpay.io/api_reference/v3#authenticate_customer_email
-func (m *Moonpay) ConfirmRegistration(email, code, extid string) (c CustomerAuth, err error) {
+func (m *Moonpay) ConfirmRegistration(email, code, extid string) (CustomerAuth, error) {
+	return m.ConfirmRegistrationContext(context.Background(), email, code, extid)
+}
+
+// ConfirmRegistrationContext is like ConfirmRegistration but with the context.
+func (m *Moonpay) ConfirmRegistrationContext(ctx context.Context, email, code, extid string) (c CustomerAuth, err error) {
 	data := email_login_data{Email: email, SecurityCode: code, ExternalCustomerId: extid}
 
-	resp, err := req.Post(
+	resp, err := m.do(ctx, "POST",
 		m.url("/customers/email_login"),
 		req.QueryParam{"apiKey": m.pubkey},
 		req.BodyJSON(data),
 	)
-	if err := m.handleError(resp, err); err != nil {
+	if err != nil {
 		return c, err
 	}
 
@@ -216,45 +352,77 @@ func (m *Moonpay) ConfirmRegistration(email, code, extid string) (c CustomerAuth
 //
 
 // MoonpayCustomer is wrapper on the Moonpay instance for join requests for
-// specified customer
+// specified customer. The token is refreshed automatically before expiration
+// and on the unauthorized response, the instance is safe for concurrent use.
 type MoonpayCustomer struct {
 	*Moonpay
-	token string
+
+	mu        sync.RWMutex
+	token     string
+	expiresAt time.Time
+	onRefresh func(CustomerAuth)
+
+	// refreshMu serializes refreshing of the token
+	refreshMu sync.Mutex
 }
 
 func (m *Moonpay) Customer(token string) *MoonpayCustomer {
-	return &MoonpayCustomer{m, token}
-}
+	c := &MoonpayCustomer{Moonpay: m}
+	c.setToken(token)
 
-func (m *MoonpayCustomer) authHeader() req.Header {
-	return req.Header{"Authorization": "Bearer " + m.token}
+	return c
 }
 
 // RefreshToken refresh the logged-in customer's JWT
 // https://www.moonpay.io/api_reference/v3#refresh_token
-func (m *MoonpayCustomer) RefreshToken() (c CustomerAuth, err error) {
-	resp, err := req.Get(
+func (m *MoonpayCustomer) RefreshToken() (CustomerAuth, error) {
+	return m.RefreshTokenContext(context.Background())
+}
+
+// RefreshTokenContext is like RefreshToken but with the context.
+func (m *MoonpayCustomer) RefreshTokenContext(ctx context.Context) (CustomerAuth, error) {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	return m.refreshToken(ctx)
+}
+
+func (m *MoonpayCustomer) refreshToken(ctx context.Context) (c CustomerAuth, err error) {
+	resp, err := m.Moonpay.do(ctx, "GET",
 		m.url("/customers/refresh_token"),
 		req.QueryParam{"apiKey": m.pubkey},
-		m.authHeader(),
+		authHeader(m.Token()),
 	)
-	if err := m.handleError(resp, err); err != nil {
+	if err != nil {
 		return c, err
 	}
 
-	err = resp.ToJSON(&c)
-	if err != nil {
-		m.token = c.Token
+	if err = resp.ToJSON(&c); err != nil {
+		return c, err
 	}
 
-	return
+	m.setToken(c.Token)
+
+	m.mu.RLock()
+	fn := m.onRefresh
+	m.mu.RUnlock()
+	if fn != nil {
+		fn(c)
+	}
+
+	return c, nil
 }
 
 // CustomerInfo retrieves the details of the logged-in customer.
 // https://www.moonpay.io/api_reference/v3#retrieve_customer
-func (m *MoonpayCustomer) Info() (c Customer, err error) {
-	resp, err := req.Get(m.url("/customers/me"), m.authHeader())
-	if err := m.handleError(resp, err); err != nil {
+func (m *MoonpayCustomer) Info() (Customer, error) {
+	return m.InfoContext(context.Background())
+}
+
+// InfoContext is like Info but with the context.
+func (m *MoonpayCustomer) InfoContext(ctx context.Context) (c Customer, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/customers/me"))
+	if err != nil {
 		return c, err
 	}
 
@@ -264,9 +432,14 @@ func (m *MoonpayCustomer) Info() (c Customer, err error) {
 
 // CustomerLimits retrieve the logged-in customer's limits
 // https://www.moonpay.io/api_reference/v3#retrieve_customer_limits
-func (m *MoonpayCustomer) Limits() (l Limits, err error) {
-	resp, err := req.Get(m.url("/customers/me/limits"), m.authHeader())
-	if err := m.handleError(resp, err); err != nil {
+func (m *MoonpayCustomer) Limits() (Limits, error) {
+	return m.LimitsContext(context.Background())
+}
+
+// LimitsContext is like Limits but with the context.
+func (m *MoonpayCustomer) LimitsContext(ctx context.Context) (l Limits, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/customers/me/limits"))
+	if err != nil {
 		return l, err
 	}
 
@@ -276,15 +449,18 @@ func (m *MoonpayCustomer) Limits() (l Limits, err error) {
 
 // UpdateCustomer by setting the values of the parameters passed.
 // https://www.moonpay.io/api_reference/v3#update_customer
-func (m *MoonpayCustomer) Update(u CustomerFields) (c Customer, err error) {
-	resp, err := req.Patch(
+func (m *MoonpayCustomer) Update(u CustomerFields) (Customer, error) {
+	return m.UpdateContext(context.Background(), u)
+}
+
+// UpdateContext is like Update but with the context.
+func (m *MoonpayCustomer) UpdateContext(ctx context.Context, u CustomerFields) (c Customer, err error) {
+	resp, err := m.do(ctx, "PATCH",
 		m.url("/customers/me"),
 		req.QueryParam{"apiKey": m.pubkey},
-		m.authHeader(),
 		req.BodyJSON(u),
 	)
-	log.Println(resp.Dump())
-	if err := m.handleError(resp, err); err != nil {
+	if err != nil {
 		return c, err
 	}
 
@@ -296,15 +472,86 @@ func (m *MoonpayCustomer) Update(u CustomerFields) (c Customer, err error) {
 // Files
 //
 
+// UploadFile uploads the identity document of the logged-in customer. Type is
+// one of Document* constants, side is required for two-sided documents and
+// country is the alpha3 code of the issuing country.
+// https://www.moonpay.io/api_reference/v3#upload_file
+func (m *MoonpayCustomer) UploadFile(typ, side, country, filename string, r io.Reader) (File, error) {
+	return m.UploadFileContext(context.Background(), typ, side, country, filename, r)
+}
+
+// UploadFileContext is like UploadFile but with the context.
+func (m *MoonpayCustomer) UploadFileContext(ctx context.Context, typ, side, country, filename string, r io.Reader) (f File, err error) {
+	rc, ok := r.(io.ReadCloser)
+	if !ok {
+		rc = ioutil.NopCloser(r)
+	}
+
+	params := req.Param{"type": typ, "country": country}
+	if side != "" {
+		params["side"] = side
+	}
+
+	resp, err := m.do(ctx, "POST",
+		m.url("/files"),
+		params,
+		req.FileUpload{FieldName: "file", FileName: filename, File: rc},
+	)
+	if err != nil {
+		return f, err
+	}
+
+	err = resp.ToJSON(&f)
+	return
+}
+
+// Files returns a list of the files uploaded by the logged-in customer
+// https://www.moonpay.io/api_reference/v3#list_files
+func (m *MoonpayCustomer) Files() ([]File, error) {
+	return m.FilesContext(context.Background())
+}
+
+// FilesContext is like Files but with the context.
+func (m *MoonpayCustomer) FilesContext(ctx context.Context) (files []File, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/files"))
+	if err != nil {
+		return nil, err
+	}
+
+	err = resp.ToJSON(&files)
+	return
+}
+
+// File retrieves the uploaded file, it allows to check the review status
+func (m *MoonpayCustomer) File(id uuid.UUID) (File, error) {
+	return m.FileContext(context.Background(), id)
+}
+
+// FileContext is like File but with the context.
+func (m *MoonpayCustomer) FileContext(ctx context.Context, id uuid.UUID) (f File, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/files/%s", id))
+	if err != nil {
+		return f, err
+	}
+
+	err = resp.ToJSON(&f)
+	return
+}
+
 //
 // Tokens
 //
 
 // CreateToken creates a single-use token that represents a credit card’s details.
 // https://www.moonpay.io/api_reference/v3#create_token
-func (m *Moonpay) CreateToken(data TokenRequest) (t Token, err error) {
-	resp, err := req.Post(m.url("/tokens"), req.QueryParam{"apiKey": m.pubkey}, req.BodyJSON(data))
-	if err := m.handleError(resp, err); err != nil {
+func (m *Moonpay) CreateToken(data TokenRequest) (Token, error) {
+	return m.CreateTokenContext(context.Background(), data)
+}
+
+// CreateTokenContext is like CreateToken but with the context.
+func (m *Moonpay) CreateTokenContext(ctx context.Context, data TokenRequest) (t Token, err error) {
+	resp, err := m.do(ctx, "POST", m.url("/tokens"), req.QueryParam{"apiKey": m.pubkey}, req.BodyJSON(data))
+	if err != nil {
 		return t, err
 	}
 
@@ -320,12 +567,17 @@ func (m *Moonpay) CreateToken(data TokenRequest) (t Token, err error) {
 // Note that you must provide the user's personal information before being able
 // to create a card.
 // https://www.moonpay.io/api_reference/v3#create_card
-func (m *MoonpayCustomer) CreateCard(tokenid uuid.UUID) (card Card, err error) {
+func (m *MoonpayCustomer) CreateCard(tokenid uuid.UUID) (Card, error) {
+	return m.CreateCardContext(context.Background(), tokenid)
+}
+
+// CreateCardContext is like CreateCard but with the context.
+func (m *MoonpayCustomer) CreateCardContext(ctx context.Context, tokenid uuid.UUID) (card Card, err error) {
 	type data struct {
 		TokenID uuid.UUID `json:"tokenId"`
 	}
-	resp, err := req.Post(m.url("/cards"), m.authHeader(), req.BodyJSON(data{tokenid}))
-	if err := m.handleError(resp, err); err != nil {
+	resp, err := m.do(ctx, "POST", m.url("/cards"), req.BodyJSON(data{tokenid}))
+	if err != nil {
 		return card, err
 	}
 
@@ -335,9 +587,14 @@ func (m *MoonpayCustomer) CreateCard(tokenid uuid.UUID) (card Card, err error) {
 
 // Cards returns a list of the cards that you have stored for the logged-in user
 // https://www.moonpay.io/api_reference/v3#list_cards
-func (m *MoonpayCustomer) Cards() (cards []Card, err error) {
-	resp, err := req.Get(m.url("/cards"), m.authHeader())
-	if err := m.handleError(resp, err); err != nil {
+func (m *MoonpayCustomer) Cards() ([]Card, error) {
+	return m.CardsContext(context.Background())
+}
+
+// CardsContext is like Cards but with the context.
+func (m *MoonpayCustomer) CardsContext(ctx context.Context) (cards []Card, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/cards"))
+	if err != nil {
 		return nil, err
 	}
 
@@ -347,9 +604,14 @@ func (m *MoonpayCustomer) Cards() (cards []Card, err error) {
 
 // DeleteCard permanently deletes a card. It cannot be undone
 // https://www.moonpay.io/api_reference/v3#delete_card
-func (m *MoonpayCustomer) DeleteCard(id uuid.UUID) (card Card, err error) {
-	resp, err := req.Delete(m.url("/cards/%s", id), m.authHeader())
-	if err := m.handleError(resp, err); err != nil {
+func (m *MoonpayCustomer) DeleteCard(id uuid.UUID) (Card, error) {
+	return m.DeleteCardContext(context.Background(), id)
+}
+
+// DeleteCardContext is like DeleteCard but with the context.
+func (m *MoonpayCustomer) DeleteCardContext(ctx context.Context, id uuid.UUID) (card Card, err error) {
+	resp, err := m.do(ctx, "DELETE", m.url("/cards/%s", id))
+	if err != nil {
 		return card, err
 	}
 
@@ -361,11 +623,36 @@ func (m *MoonpayCustomer) DeleteCard(id uuid.UUID) (card Card, err error) {
 // Transactions
 //
 
-// CreateTransaction creates a new transaction object
+// CreateTransaction creates a new transaction object, the request is checked by
+// ValidateTransaction before sending. On the failure the returned transaction
+// has only ExternalTransactionID to find it by TransactionByExternalID.
 // https://www.moonpay.io/api_reference/v3#create_transaction
-func (m *MoonpayCustomer) CreateTransaction(data TransactionRequest) (tx Transaction, err error) {
-	resp, err := req.Get(m.url("/transactions"), m.authHeader(), req.BodyJSON(data))
-	if err := m.handleError(resp, err); err != nil {
+func (m *MoonpayCustomer) CreateTransaction(data TransactionRequest) (Transaction, error) {
+	return m.CreateTransactionContext(context.Background(), data)
+}
+
+// CreateTransactionContext is like CreateTransaction but with the context.
+func (m *MoonpayCustomer) CreateTransactionContext(ctx context.Context, data TransactionRequest) (tx Transaction, err error) {
+	if data.ExternalTransactionID == "" {
+		data.ExternalTransactionID = uuid.New().String()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// the failed transaction keeps the external ID to find it later
+	tx.ExternalTransactionID = data.ExternalTransactionID
+
+	if err := m.ValidateTransactionContext(ctx, data); err != nil {
+		return tx, err
+	}
+
+	if IdempotencyKey(ctx) == "" {
+		ctx = WithIdempotencyKey(ctx, data.ExternalTransactionID)
+	}
+
+	resp, err := m.do(ctx, "POST", m.url("/transactions"), req.BodyJSON(data))
+	if err != nil {
 		return tx, err
 	}
 
@@ -376,9 +663,14 @@ func (m *MoonpayCustomer) CreateTransaction(data TransactionRequest) (tx Transac
 // Transaction retrieve the details of an existing transaction. Supply the unique
 // transaction identifier that was returned upon transaction creation.
 // https://www.moonpay.io/api_reference/v3#retrieve_transaction
-func (m *MoonpayCustomer) Transaction(id uuid.UUID) (tx Transaction, err error) {
-	resp, err := req.Get(m.url("/transactions/%s", id), m.authHeader())
-	if err := m.handleError(resp, err); err != nil {
+func (m *MoonpayCustomer) Transaction(id uuid.UUID) (Transaction, error) {
+	return m.TransactionContext(context.Background(), id)
+}
+
+// TransactionContext is like Transaction but with the context.
+func (m *MoonpayCustomer) TransactionContext(ctx context.Context, id uuid.UUID) (tx Transaction, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/transactions/%s", id))
+	if err != nil {
 		return tx, err
 	}
 
@@ -386,11 +678,41 @@ func (m *MoonpayCustomer) Transaction(id uuid.UUID) (tx Transaction, err error)
 	return
 }
 
+// TransactionByExternalID retrieve the transaction by the external transaction
+// identifier, returns ErrNotFound if the transaction was not created.
+// https://www.moonpay.io/api_reference/v3#retrieve_transaction_by_external_id
+func (m *MoonpayCustomer) TransactionByExternalID(id string) (Transaction, error) {
+	return m.TransactionByExternalIDContext(context.Background(), id)
+}
+
+// TransactionByExternalIDContext is like TransactionByExternalID but with the context.
+func (m *MoonpayCustomer) TransactionByExternalIDContext(ctx context.Context, id string) (tx Transaction, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/transactions/ext/%s", id))
+	if err != nil {
+		return tx, err
+	}
+
+	var txs []Transaction
+	if err = resp.ToJSON(&txs); err != nil {
+		return tx, err
+	}
+	if len(txs) == 0 {
+		return tx, ErrNotFound
+	}
+
+	return txs[0], nil
+}
+
 // Transactions returns a list of the logged-in customer's transactions
 // https://www.moonpay.io/api_reference/v3#list_transactions
-func (m *MoonpayCustomer) Transactions() (txs []Transaction, err error) {
-	resp, err := req.Get(m.url("/transactions"), m.authHeader())
-	if err := m.handleError(resp, err); err != nil {
+func (m *MoonpayCustomer) Transactions() ([]Transaction, error) {
+	return m.TransactionsContext(context.Background())
+}
+
+// TransactionsContext is like Transactions but with the context.
+func (m *MoonpayCustomer) TransactionsContext(ctx context.Context) (txs []Transaction, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/transactions"))
+	if err != nil {
 		return nil, err
 	}
 
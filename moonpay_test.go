@@ -1,13 +1,92 @@
 package moonpay
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/Pallinder/go-randomdata"
+	"github.com/sg3des/moonpay/moonpaytest"
 )
 
-var testMoonpay = New(os.Getenv("MOONPAY_KEY"))
+var (
+	testMoonpay *Moonpay
+	testEmail   = os.Getenv("TEST_EMAIL")
+	testCode    = os.Getenv("TEST_CODE")
+	testToken   = os.Getenv("TEST_TOKEN")
+)
+
+// TestMain runs tests against the live API if MOONPAY_KEY is set, otherwise
+// against the fake server. If MOONPAY_CASSETTE is set, exchanges with the live
+// API are recorded to the cassette, or the cassette is replayed without the
+// key.
+func TestMain(m *testing.M) {
+	key := os.Getenv("MOONPAY_KEY")
+
+	if path := os.Getenv("MOONPAY_CASSETTE"); path != "" {
+		os.Exit(runCassette(m, path, key))
+	}
+
+	if key != "" {
+		testMoonpay = New(key)
+		os.Exit(m.Run())
+	}
+
+	srv := moonpaytest.NewServer()
+	testMoonpay = New(srv.PublishableKey, WithBaseURL(srv.APIURL()))
+	testEmail = "test@example.com"
+	testCode = srv.SecurityCode
+	testToken = srv.Login(testEmail)
+
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
+
+// runCassette records the cassette if the key is set, otherwise replays it
+// with the scrubbed credentials. Random data is seeded so the requests are the
+// same in both modes.
+func runCassette(m *testing.M, path, key string) int {
+	randomdata.CustomRand(rand.New(rand.NewSource(1)))
+
+	mode := moonpaytest.ModeReplay
+	if key != "" {
+		mode = moonpaytest.ModeRecord
+	}
+
+	rec, err := moonpaytest.NewRecorder(path, mode, nil)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "cassette %s is not recorded, set MOONPAY_KEY to record it\n", path)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if mode == moonpaytest.ModeReplay {
+		key = moonpaytest.ScrubbedKey
+		testEmail = moonpaytest.ScrubbedEmail
+		testCode = moonpaytest.ScrubbedCode
+		testToken = moonpaytest.ScrubbedToken
+	}
+	testMoonpay = New(key, WithTransport(rec))
+
+	code := m.Run()
+	if err := rec.Save(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return code
+}
 
 func TestCurrencies(t *testing.T) {
 	list, err := testMoonpay.Currencies()
@@ -45,6 +124,76 @@ func TestCurrenciesPrice(t *testing.T) {
 	t.Log(prices)
 }
 
+func TestCurrencyQuote(t *testing.T) {
+	q, err := testMoonpay.CurrencyQuote("btc", "eur", NewAmount(100, 0), NewAmount(1, 0))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if !q.TotalAmount.Equal(NewAmount(100, 0)) {
+		t.Errorf("total amount should be equal to the spent amount: %+v", q)
+	}
+	if q.QuoteCurrencyAmount.Sign() <= 0 || q.FeeAmount.Sign() <= 0 || q.ExtraFeeAmount.Sign() <= 0 {
+		t.Errorf("unexpected quote: %+v", q)
+	}
+
+	t.Logf("%+v", q)
+}
+
+func TestCurrencyQuoteReceive(t *testing.T) {
+	q, err := testMoonpay.CurrencyQuoteReceive("btc", "eur", MustParseAmount("0.01"), Amount{})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if !q.QuoteCurrencyAmount.Equal(MustParseAmount("0.01")) {
+		t.Errorf("quote amount should be equal to the received amount: %+v", q)
+	}
+	if q.TotalAmount.Cmp(q.BaseCurrencyAmount) <= 0 {
+		t.Errorf("fees should be added to the total amount: %+v", q)
+	}
+
+	t.Logf("%+v", q)
+}
+
+func TestContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := testMoonpay.CurrenciesContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled error, got: %v", err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/currencies" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if ua := r.Header.Get("User-Agent"); ua != "test-agent" {
+			t.Errorf("unexpected user agent: %s", ua)
+		}
+
+		w.Write([]byte(`[{"code":"btc"}]`))
+	}))
+	defer srv.Close()
+
+	m := New("", WithBaseURL(srv.URL+"/api/v3"), WithHTTPClient(srv.Client()), WithUserAgent("test-agent"))
+
+	list, err := m.Currencies()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if len(list) != 1 || list[0].Code != "btc" {
+		t.Errorf("unexpected currencies: %+v", list)
+	}
+}
+
 //
 //
 //
@@ -78,7 +227,7 @@ func TestIPaddress(t *testing.T) {
 //
 
 func TestSecurityCode(t *testing.T) {
-	preauth, err := testMoonpay.SecurityCode(os.Getenv("TEST_EMAIL"))
+	preauth, err := testMoonpay.SecurityCode(testEmail)
 	if err != nil {
 		t.Error(err)
 	}
@@ -87,12 +236,12 @@ func TestSecurityCode(t *testing.T) {
 }
 
 func TestConfirmRegistration(t *testing.T) {
-	code := os.Getenv("TEST_CODE")
+	code := testCode
 	if code == "" {
 		t.Skip("security code not specified, check email and set code to TEST_CODE environment variable")
 	}
 
-	c, err := testMoonpay.ConfirmRegistration(os.Getenv("TEST_EMAIL"), code, "some-test-id")
+	c, err := testMoonpay.ConfirmRegistration(testEmail, code, "some-test-id")
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
@@ -102,7 +251,7 @@ func TestConfirmRegistration(t *testing.T) {
 }
 
 func TestRefreshToken(t *testing.T) {
-	token := os.Getenv("TEST_TOKEN")
+	token := testToken
 	if token == "" {
 		t.Skip("auth token is not specified, set valid token to TEST_TOKEN environment variable")
 	}
@@ -117,7 +266,7 @@ func TestRefreshToken(t *testing.T) {
 }
 
 func TestCustomerInfo(t *testing.T) {
-	token := os.Getenv("TEST_TOKEN")
+	token := testToken
 	if token == "" {
 		t.Skip("auth token is not specified, set valid token to TEST_TOKEN environment variable")
 	}
@@ -132,7 +281,7 @@ func TestCustomerInfo(t *testing.T) {
 }
 
 func TestCustomerLimits(t *testing.T) {
-	token := os.Getenv("TEST_TOKEN")
+	token := testToken
 	if token == "" {
 		t.Skip("auth token is not specified, set valid token to TEST_TOKEN environment variable")
 	}
@@ -147,7 +296,7 @@ func TestCustomerLimits(t *testing.T) {
 }
 
 func TestUpdateCustomer(t *testing.T) {
-	token := os.Getenv("TEST_TOKEN")
+	token := testToken
 	if token == "" {
 		t.Skip("auth token is not specified, set valid token to TEST_TOKEN environment variable")
 	}
@@ -166,3 +315,242 @@ func TestUpdateCustomer(t *testing.T) {
 
 	t.Logf("%+v", c)
 }
+
+func TestFiles(t *testing.T) {
+	token := testToken
+	if token == "" {
+		t.Skip("auth token is not specified, set valid token to TEST_TOKEN environment variable")
+	}
+
+	customer := testMoonpay.Customer(token)
+	f, err := customer.UploadFile(DocumentPassport, SideFront, "GBR", "passport.jpg", bytes.NewReader([]byte("\xff\xd8\xff")))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if f.Type != DocumentPassport || f.Country != "GBR" {
+		t.Errorf("unexpected file: %+v", f)
+	}
+
+	files, err := customer.Files()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(files) == 0 {
+		t.Error("files not found")
+	}
+
+	f, err = customer.File(f.ID)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	t.Logf("%+v", f)
+}
+
+//
+//
+//
+
+func TestCards(t *testing.T) {
+	token := testToken
+	if token == "" {
+		t.Skip("auth token is not specified, set valid token to TEST_TOKEN environment variable")
+	}
+
+	customer := testMoonpay.Customer(token)
+	_, err := customer.Update(CustomerFields{FirstName: "John", LastName: "Doe"})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	cardtoken, err := testMoonpay.CreateToken(TokenRequest{
+		Number:     "4111111111111111",
+		ExpiryDate: "12/30",
+		CVC:        "123",
+		Address:    Address{Street: "1 High Street", Town: "London", PostCode: "N1 1AA", Country: "GBR"},
+	})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	card, err := customer.CreateCard(cardtoken.ID)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if card.LastDigits != "1111" {
+		t.Errorf("unexpected last digits of the card: %s", card.LastDigits)
+	}
+
+	cards, err := customer.Cards()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(cards) == 0 {
+		t.Error("cards not found")
+	}
+
+	if _, err := customer.DeleteCard(card.ID); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	token := testToken
+	if token == "" {
+		t.Skip("auth token is not specified, set valid token to TEST_TOKEN environment variable")
+	}
+
+	txs, err := testMoonpay.Customer(token).Transactions()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	t.Logf("%+v", txs)
+}
+
+func TestTransactionByExternalID(t *testing.T) {
+	token := testToken
+	if token == "" {
+		t.Skip("auth token is not specified, set valid token to TEST_TOKEN environment variable")
+	}
+
+	_, err := testMoonpay.Customer(token).TransactionByExternalID(randomdata.Alphanumeric(16))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+// transportFunc is the RoundTripper function
+type transportFunc func(*http.Request) (*http.Response, error)
+
+func (f transportFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestCreateTransaction(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	var key, reused string
+	var lost bool
+	m := New(srv.PublishableKey,
+		WithBaseURL(srv.APIURL()),
+		WithRetry(testRetryPolicy),
+		WithTransport(transportFunc(func(r *http.Request) (*http.Response, error) {
+			create := r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/transactions")
+			if create {
+				key = r.Header.Get(IdempotencyKeyHeader)
+			} else if k := r.Header.Get(IdempotencyKeyHeader); k != "" {
+				reused = r.Method + " " + r.URL.Path
+			}
+			resp, err := http.DefaultTransport.RoundTrip(r)
+			if err == nil && create && lost {
+				resp.Body.Close()
+				return nil, errors.New("response is lost")
+			}
+			return resp, err
+		})),
+	)
+	customer := m.Customer(srv.Login("transaction@example.com"))
+
+	cardtoken, err := m.CreateToken(TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := TransactionRequest{
+		BaseCurrencyAmount: NewAmount(100, 0),
+		BaseCurrencyCode:   "eur",
+		CurrencyCode:       "btc",
+		WalletAddress:      "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		TokenID:            cardtoken.ID.String(),
+	}
+	tx, err := customer.CreateTransaction(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Status != StatusPending {
+		t.Errorf("unexpected status %s", tx.Status)
+	}
+	if tx.ExternalTransactionID == "" {
+		t.Error("external transaction ID is not generated")
+	}
+	if key != tx.ExternalTransactionID {
+		t.Errorf("idempotency key %q does not match external ID %q", key, tx.ExternalTransactionID)
+	}
+	if reused != "" {
+		t.Errorf("idempotency key is sent with %s", reused)
+	}
+
+	found, err := customer.TransactionByExternalID(tx.ExternalTransactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != tx.ID {
+		t.Errorf("found transaction %s, expected %s", found.ID, tx.ID)
+	}
+
+	// the repeated request does not create a duplicate
+	data.ExternalTransactionID = tx.ExternalTransactionID
+	repeated, err := customer.CreateTransactionContext(WithIdempotencyKey(context.Background(), tx.ExternalTransactionID), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repeated.ID != tx.ID {
+		t.Errorf("duplicate transaction %s is created", repeated.ID)
+	}
+	if reused != "" {
+		t.Errorf("idempotency key of the context is sent with %s", reused)
+	}
+
+	// the generated external ID is returned with the error
+	cardtoken, err = m.CreateToken(TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.ExternalTransactionID, data.TokenID = "", cardtoken.ID.String()
+	lost = true
+	failed, err := customer.CreateTransaction(data)
+	lost = false
+	if err == nil || failed.ExternalTransactionID == "" {
+		t.Fatalf("expected error with external ID, got %q %v", failed.ExternalTransactionID, err)
+	}
+	if found, err := customer.TransactionByExternalID(failed.ExternalTransactionID); err != nil || found.ID == tx.ID {
+		t.Errorf("created transaction is not found: %v", err)
+	}
+
+	// the external ID is escaped in the path
+	cardtoken, err = m.CreateToken(TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.ExternalTransactionID, data.TokenID = "order/1 ?#%", cardtoken.ID.String()
+	escaped, err := customer.CreateTransaction(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := customer.TransactionByExternalID("order/1 ?#%"); err != nil || found.ID != escaped.ID {
+		t.Errorf("transaction is not found by the external ID with special characters: %v", err)
+	}
+}
+
+func TestRespError(t *testing.T) {
+	_, err := testMoonpay.Customer("invalid").Info()
+	if err == nil {
+		t.Error("expected error with invalid token")
+		t.FailNow()
+	}
+
+	if _, ok := err.(*RespError); !ok {
+		t.Errorf("unexpected error type %T: %v", err, err)
+	}
+}
@@ -0,0 +1,414 @@
+package moonpaytest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
+// Values replacing secrets and personal data in cassettes, tests replaying the
+// cassette use them as the key, the email, the code and the token
+const (
+	ScrubbedKey   = "pk_test_scrubbed"
+	ScrubbedEmail = "user@example.com"
+	ScrubbedToken = "scrubbed-token"
+	ScrubbedCode  = "000000"
+	ScrubbedCard  = "4111111111111111"
+	ScrubbedCVC   = "000"
+	ScrubbedCSRF  = "scrubbed-csrf-token"
+	ScrubbedPhone = "+440000000000"
+	ScrubbedSSN   = "000-00-0000"
+
+	// ScrubbedDateOfBirth replaces the date, the time is kept if the date
+	// includes it
+	ScrubbedDateOfBirth = "1990-01-01"
+)
+
+// ErrInteractionNotFound is returned by the replaying Recorder if the request
+// was not recorded
+var ErrInteractionNotFound = errors.New("moonpaytest: interaction not found in cassette")
+
+// Mode is the mode of the Recorder
+type Mode int
+
+const (
+	// ModeReplay responds with recorded responses without the network
+	ModeReplay Mode = iota
+
+	// ModeRecord sends requests by the transport and records exchanges
+	ModeRecord
+)
+
+// Cassette is the file of recorded exchanges
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is the recorded exchange
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the scrubbed request, only Content-Type header is kept
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// RecordedResponse is the scrubbed response
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Body is the recorded body, the text is kept as the string and the binary
+// data is encoded by base64
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+
+	var enc struct {
+		Base64 []byte `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return err
+	}
+	*b = enc.Base64
+
+	return nil
+}
+
+// Recorder is the RoundTripper recording exchanges with the MoonPay API to the
+// cassette file or replaying them. Tokens, emails, card numbers, security
+// codes, API keys and personal data of customers are scrubbed before
+// recording.
+//
+// Replayed requests are matched by the method and the scrubbed URL in the
+// order of recording, IDs in the path are ignored if there is no exact match.
+// The last matched GET is repeated if it is requested more times than
+// recorded.
+//
+//	rec, err := moonpaytest.NewRecorder("testdata/moonpay.json", moonpaytest.ModeReplay, nil)
+//	mpay := moonpay.New(moonpaytest.ScrubbedKey, moonpay.WithTransport(rec))
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder returns the recorder of the cassette file. In the record mode
+// requests are sent by the transport, http.DefaultTransport if it is nil, and
+// the cassette is written by Save. In the replay mode the cassette is read.
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{mode: mode, path: path, transport: transport}
+
+	if mode == ModeReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("moonpaytest: invalid cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Mode returns the mode of the recorder
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    scrubText(req.URL.String()),
+		Body:   scrubBody(body),
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		recorded.Header = http.Header{"Content-Type": {ct}}
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: scrubHeader(resp.Header),
+			Body:   scrubBody(respBody),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Save writes recorded interactions to the cassette file
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.match(recorded)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, recorded.Method, recorded.URL)
+	}
+	r.used[i] = true
+
+	rec := r.cassette.Interactions[i].Response
+	header := rec.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// match returns the index of the interaction to replay or -1
+func (r *Recorder) match(req RecordedRequest) int {
+	exact := func(rec RecordedRequest) bool {
+		return rec.Method == req.Method && rec.URL == req.URL
+	}
+	similar := func(rec RecordedRequest) bool {
+		return rec.Method == req.Method && idRegex.ReplaceAllString(rec.URL, "{id}") == idRegex.ReplaceAllString(req.URL, "{id}")
+	}
+
+	for _, eq := range []func(RecordedRequest) bool{exact, similar} {
+		last := -1
+		for i, in := range r.cassette.Interactions {
+			if !eq(in.Request) {
+				continue
+			}
+			if !r.used[i] {
+				return i
+			}
+			last = i
+		}
+		if last >= 0 && req.Method == "GET" {
+			return last
+		}
+	}
+
+	return -1
+}
+
+//
+// Scrubbing
+//
+
+var (
+	idRegex    = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	jwtRegex   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	keyRegex   = regexp.MustCompile(`\b(pk|sk)_(live|test)_[A-Za-z0-9]+`)
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// scrubbedFields are replacements of JSON fields values
+var scrubbedFields = map[string]string{
+	"token":                ScrubbedToken,
+	"accessToken":          ScrubbedToken,
+	"refreshToken":         ScrubbedToken,
+	"csrfToken":            ScrubbedCSRF,
+	"email":                ScrubbedEmail,
+	"securityCode":         ScrubbedCode,
+	"number":               ScrubbedCard,
+	"cardNumber":           ScrubbedCard,
+	"cvc":                  ScrubbedCVC,
+	"phoneNumber":          ScrubbedPhone,
+	"socialSecurityNumber": ScrubbedSSN,
+}
+
+// scrubbedAddress are replacements of fields of address objects, the country
+// is kept
+var scrubbedAddress = map[string]string{
+	"street":    "1 Scrubbed Street",
+	"subStreet": "",
+	"town":      "Scrubbed",
+	"postCode":  "00000",
+	"state":     "",
+}
+
+// scrubText replaces tokens, API keys and emails in the text
+func scrubText(s string) string {
+	s = jwtRegex.ReplaceAllString(s, ScrubbedToken)
+	s = keyRegex.ReplaceAllString(s, "${1}_test_scrubbed")
+	s = emailRegex.ReplaceAllString(s, ScrubbedEmail)
+
+	return s
+}
+
+// scrubBody replaces secrets in fields of the JSON body and in the text, the
+// binary body is kept as is
+func scrubBody(body []byte) Body {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err == nil {
+		if data, err := json.Marshal(scrubValue(v)); err == nil {
+			return Body(scrubText(string(data)))
+		}
+	}
+
+	if !utf8.Valid(body) {
+		return body
+	}
+	return Body(scrubText(string(body)))
+}
+
+func scrubValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			s, ok := value.(string)
+			switch {
+			case ok && s != "" && key == "dateOfBirth":
+				v[key] = ScrubbedDateOfBirth
+				if len(s) > len(ScrubbedDateOfBirth) {
+					v[key] = ScrubbedDateOfBirth + s[len(ScrubbedDateOfBirth):]
+				}
+			case ok && s != "" && scrubbedFields[key] != "":
+				v[key] = scrubbedFields[key]
+			case key == "address" || key == "billingAddress":
+				v[key] = scrubAddress(value)
+			default:
+				v[key] = scrubValue(value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = scrubValue(v[i])
+		}
+	}
+
+	return v
+}
+
+// scrubAddress replaces non-empty fields of the address object
+func scrubAddress(v interface{}) interface{} {
+	addr, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	for key, value := range addr {
+		if s, ok := value.(string); ok && s != "" {
+			if repl, ok := scrubbedAddress[key]; ok {
+				addr[key] = repl
+			}
+		}
+	}
+
+	return addr
+}
+
+// scrubHeader drops cookies and scrubs values of the response header
+func scrubHeader(h http.Header) http.Header {
+	scrubbed := make(http.Header, len(h))
+	for key, values := range h {
+		// the length is changed by scrubbing
+		if key == "Set-Cookie" || key == "Content-Length" {
+			continue
+		}
+		for _, v := range values {
+			scrubbed.Add(key, scrubText(v))
+		}
+	}
+
+	return scrubbed
+}
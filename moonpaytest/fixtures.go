@@ -0,0 +1,111 @@
+package moonpaytest
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var fixtureTime = time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// id returns stable identifier for the fixture object
+func id(name string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("moonpaytest/"+name))
+}
+
+func fiat(code, name string) Currency {
+	return Currency{
+		ID:               id("currency/" + code),
+		CreatedAt:        fixtureTime,
+		UpdatedAt:        fixtureTime,
+		Type:             "fiat",
+		Name:             name,
+		Code:             code,
+		Precision:        2,
+		SupportsTestMode: true,
+		IsSupportedInUS:  true,
+	}
+}
+
+func crypto(code, name string, precision int, regex, testnet string) Currency {
+	return Currency{
+		ID:                  id("currency/" + code),
+		CreatedAt:           fixtureTime,
+		UpdatedAt:           fixtureTime,
+		Type:                "crypto",
+		Name:                name,
+		Code:                code,
+		Precision:           precision,
+		AddressRegex:        regex,
+		TestnetAddressRegex: testnet,
+		SupportsTestMode:    true,
+		IsSupportedInUS:     true,
+	}
+}
+
+// Currencies is the default list of currencies served by the Server
+func Currencies() []Currency {
+	xrp := crypto("xrp", "Ripple", 6, `^r[1-9A-HJ-NP-Za-km-z]{25,34}$`, `^r[1-9A-HJ-NP-Za-km-z]{25,34}$`)
+	xrp.SupportsAddressTag = true
+	xrp.AddressTagRegex = `^\d{1,10}$`
+	xrp.IsSupportedInUS = false
+
+	xlm := crypto("xlm", "Stellar", 5, `^G[A-D][A-Z2-7]{54}$`, `^G[A-D][A-Z2-7]{54}$`)
+	xlm.SupportsAddressTag = true
+	xlm.AddressTagRegex = `^[ -~]{1,28}$`
+	xlm.SupportsTestMode = false
+
+	dash := crypto("dash", "Dash", 6, `^X[1-9A-HJ-NP-Za-km-z]{33}$`, `^[7y][1-9A-HJ-NP-Za-km-z]{33}$`)
+	dash.IsSuspended = true
+
+	return []Currency{
+		fiat("eur", "Euro"),
+		fiat("usd", "US Dollar"),
+		fiat("gbp", "Pound Sterling"),
+		crypto("btc", "Bitcoin", 5,
+			`^(bc1|[13])[a-zA-HJ-NP-Z0-9]{25,39}$`,
+			`^(tb1|[2nm]|bcrt)[a-zA-HJ-NP-Z0-9]{25,40}$`),
+		crypto("eth", "Ethereum", 4,
+			`^(0x)[0-9A-Fa-f]{40}$`,
+			`^(0x)[0-9A-Fa-f]{40}$`),
+		crypto("bch", "Bitcoin Cash", 5,
+			`^(bitcoincash:)?[qp][a-z0-9]{41}$|^[13][a-km-zA-HJ-NP-Z1-9]{25,34}$`,
+			`^(bchtest:)?[qp][a-z0-9]{41}$|^[mn2][a-km-zA-HJ-NP-Z1-9]{25,34}$`),
+		crypto("ltc", "Litecoin", 5,
+			`^(L|M|3|ltc1)[a-km-zA-HJ-NP-Z0-9]{26,40}$`,
+			`^(tltc1|[mn2Q])[a-zA-HJ-NP-Z0-9]{25,40}$`),
+		crypto("usdc", "USD Coin", 2,
+			`^(0x)[0-9A-Fa-f]{40}$`,
+			`^(0x)[0-9A-Fa-f]{40}$`),
+		xrp,
+		xlm,
+		dash,
+	}
+}
+
+// Prices is the default exchange rates of the cryptocurrencies
+func Prices() map[string]map[string]float64 {
+	return map[string]map[string]float64{
+		"BTC":  {"EUR": 8524.35, "USD": 9469.11, "GBP": 7702.82},
+		"ETH":  {"EUR": 182.47, "USD": 202.69, "GBP": 164.88},
+		"BCH":  {"EUR": 254.12, "USD": 282.29, "GBP": 229.63},
+		"LTC":  {"EUR": 51.33, "USD": 57.02, "GBP": 46.38},
+		"USDC": {"EUR": 0.9, "USD": 1, "GBP": 0.81},
+		"XRP":  {"EUR": 0.1784, "USD": 0.1982, "GBP": 0.1612},
+		"XLM":  {"EUR": 0.0528, "USD": 0.0586, "GBP": 0.0477},
+		"DASH": {"EUR": 64.17, "USD": 71.28, "GBP": 57.98},
+	}
+}
+
+// Countries is the default list of countries served by the Server
+func Countries() []Country {
+	docs := []string{"passport", "national_identity_card", "driving_licence"}
+
+	return []Country{
+		{Alpha2: "GB", Alpha3: "GBR", IsAllowed: true, Name: "United Kingdom", SupportedDocuments: docs},
+		{Alpha2: "DE", Alpha3: "DEU", IsAllowed: true, Name: "Germany", SupportedDocuments: docs},
+		{Alpha2: "FR", Alpha3: "FRA", IsAllowed: true, Name: "France", SupportedDocuments: docs},
+		{Alpha2: "US", Alpha3: "USA", IsAllowed: true, Name: "United States of America", SupportedDocuments: []string{"passport", "driving_licence"}},
+		{Alpha2: "CA", Alpha3: "CAN", IsAllowed: false, Name: "Canada", SupportedDocuments: []string{"passport"}},
+	}
+}
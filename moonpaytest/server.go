@@ -0,0 +1,1374 @@
+// Package moonpaytest implements in-memory fake of the MoonPay API for offline
+// tests of the moonpay package and applications built on it.
+//
+//	srv := moonpaytest.NewServer()
+//	defer srv.Close()
+//
+//	mpay := moonpay.New(srv.PublishableKey, moonpay.WithBaseURL(srv.APIURL()))
+//	customer := mpay.Customer(srv.Login("john@example.com"))
+package moonpaytest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPublishableKey is the API key accepted by the server by default
+	DefaultPublishableKey = "pk_test_moonpaytest"
+
+	// DefaultSecurityCode is the one-time code accepted on email login
+	DefaultSecurityCode = "123456"
+
+	// DailyLimit and MonthlyLimit are limits of each customer in EUR
+	DailyLimit   = 2000
+	MonthlyLimit = 10000
+
+	// MinAmount is the minimal amount of the transaction in EUR
+	MinAmount = 20
+
+	// FeePercentage and MinFee are the MoonPay fee of the purchase, the fee is
+	// the maximum of FeePercentage of the amount and MinFee
+	FeePercentage = 4.5
+	MinFee        = 3.99
+
+	// NetworkFee is the fee of the blockchain in the base currency
+	NetworkFee = 0.99
+
+	// QuoteTTL is the lifetime of the quote
+	QuoteTTL = 30 * time.Second
+)
+
+// Server is an in-memory fake of the MoonPay API listening on the local
+// address, it is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// PublishableKey is the API key accepted by the server
+	PublishableKey string
+
+	// SecurityCode is the one-time code accepted by the email login
+	SecurityCode string
+
+	// TokenTTL is the lifetime of issued customer tokens
+	TokenTTL time.Duration
+
+	// Now returns the current time, it may be replaced to control expiration
+	Now func() time.Time
+
+	mu       sync.Mutex
+	secret   []byte
+	failures []int
+
+	currencies []Currency
+	prices     map[string]map[string]float64
+	countries  []Country
+
+	customers    map[uuid.UUID]*Customer
+	emails       map[string]uuid.UUID
+	spent        map[uuid.UUID]float64
+	files        map[uuid.UUID]*File
+	tokens       map[uuid.UUID]*Token
+	cards        map[uuid.UUID]*Card
+	transactions map[uuid.UUID]*Transaction
+}
+
+// NewServer starts and returns a new fake server, the caller should call
+// Close when finished
+func NewServer() *Server {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+
+	s := &Server{
+		PublishableKey: DefaultPublishableKey,
+		SecurityCode:   DefaultSecurityCode,
+		TokenTTL:       time.Hour,
+		Now:            time.Now,
+
+		secret:       secret,
+		currencies:   Currencies(),
+		prices:       Prices(),
+		countries:    Countries(),
+		customers:    make(map[uuid.UUID]*Customer),
+		emails:       make(map[string]uuid.UUID),
+		spent:        make(map[uuid.UUID]float64),
+		files:        make(map[uuid.UUID]*File),
+		tokens:       make(map[uuid.UUID]*Token),
+		cards:        make(map[uuid.UUID]*Card),
+		transactions: make(map[uuid.UUID]*Transaction),
+	}
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+// APIURL returns base address of the API to pass it to the client
+func (s *Server) APIURL() string {
+	return s.URL + "/v3"
+}
+
+// SetCurrencies replaces list of the served currencies
+func (s *Server) SetCurrencies(list []Currency) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.currencies = list
+}
+
+// SetPrices replaces exchange rates, the keys are uppercase currency codes
+func (s *Server) SetPrices(prices map[string]map[string]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prices = prices
+}
+
+// Login registers the customer with specified email if it does not exist and
+// returns its token, it skips the security code step.
+func (s *Server) Login(email string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.issueToken(s.customer(email, ""))
+}
+
+// SetTransactionStatus changes status of the transaction as the MoonPay does
+// on processing of the payment.
+func (s *Server) SetTransactionStatus(id uuid.UUID, status, failureReason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, ok := s.transactions[id]
+	if !ok {
+		return fmt.Errorf("moonpaytest: transaction %s not found", id)
+	}
+
+	tx.Status = status
+	tx.FailureReason = failureReason
+	tx.UpdatedAt = s.Now().UTC()
+	if status == "completed" && tx.CryptoTransactionID == "" {
+		tx.CryptoTransactionID = fmt.Sprintf("%x", sha256.Sum256(tx.ID[:]))
+	}
+
+	return nil
+}
+
+// SetFileStatus changes review status of the uploaded file
+func (s *Server) SetFileStatus(id uuid.UUID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[id]
+	if !ok {
+		return fmt.Errorf("moonpaytest: file %s not found", id)
+	}
+
+	f.Status = status
+	f.UpdatedAt = s.Now().UTC()
+
+	return nil
+}
+
+// FailNext makes the next n requests fail with the status, it allows to test
+// retrying of transient errors
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/v3/") {
+		notFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+
+		name := strings.Replace(http.StatusText(status), " ", "", -1) + "Error"
+		writeError(w, status, name, http.StatusText(status))
+		return
+	}
+
+	route := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), "/v3/"), "/"), "/")
+	for i, p := range route {
+		route[i], _ = url.PathUnescape(p)
+	}
+	switch {
+	case match(r, route, "GET", "currencies"):
+		writeJSON(w, http.StatusOK, s.currencies)
+	case match(r, route, "GET", "currencies", "price"):
+		s.currenciesPrice(w, r)
+	case match(r, route, "GET", "currencies", "*", "price"):
+		s.currencyPrice(w, r, route[1])
+	case match(r, route, "GET", "currencies", "*", "quote"):
+		s.currencyQuote(w, r, route[1])
+	case match(r, route, "GET", "countries"):
+		writeJSON(w, http.StatusOK, s.countries)
+	case match(r, route, "GET", "ip_address"):
+		s.ipAddress(w, r)
+	case match(r, route, "POST", "customers", "email_login"):
+		s.emailLogin(w, r)
+	case match(r, route, "GET", "customers", "refresh_token"):
+		s.refreshToken(w, r)
+	case match(r, route, "GET", "customers", "me"):
+		s.customerInfo(w, r)
+	case match(r, route, "PATCH", "customers", "me"):
+		s.updateCustomer(w, r)
+	case match(r, route, "GET", "customers", "me", "limits"):
+		s.limits(w, r)
+	case match(r, route, "POST", "files"):
+		s.uploadFile(w, r)
+	case match(r, route, "GET", "files"):
+		s.listFiles(w, r)
+	case match(r, route, "GET", "files", "*"):
+		s.file(w, r, route[1])
+	case match(r, route, "POST", "tokens"):
+		s.createToken(w, r)
+	case match(r, route, "POST", "cards"):
+		s.createCard(w, r)
+	case match(r, route, "GET", "cards"):
+		s.listCards(w, r)
+	case match(r, route, "DELETE", "cards", "*"):
+		s.deleteCard(w, r, route[1])
+	case match(r, route, "POST", "transactions"):
+		s.createTransaction(w, r)
+	case match(r, route, "GET", "transactions"):
+		s.listTransactions(w, r)
+	case match(r, route, "GET", "transactions", "ext", "*"):
+		s.transactionsByExternalID(w, r, route[2])
+	case match(r, route, "GET", "transactions", "*"):
+		s.transaction(w, r, route[1])
+	default:
+		notFound(w, r)
+	}
+}
+
+func match(r *http.Request, route []string, method string, pattern ...string) bool {
+	if r.Method != method || len(route) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != route[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+//
+// Currencies
+//
+
+func (s *Server) currenciesPrice(w http.ResponseWriter, r *http.Request) {
+	if !s.checkKey(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	prices := make(map[string]map[string]float64)
+	for _, crypto := range splitCodes(q.Get("cryptoCurrencies")) {
+		rates, ok := s.prices[crypto]
+		if !ok {
+			continue
+		}
+
+		prices[crypto] = make(map[string]float64)
+		for _, fiat := range splitCodes(q.Get("fiatCurrencies")) {
+			if rate, ok := rates[fiat]; ok {
+				prices[crypto][fiat] = rate
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, prices)
+}
+
+func (s *Server) currencyPrice(w http.ResponseWriter, r *http.Request, code string) {
+	if !s.checkKey(w, r) {
+		return
+	}
+
+	rates, ok := s.prices[strings.ToUpper(code)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFoundError", "Currency not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rates)
+}
+
+func (s *Server) currencyQuote(w http.ResponseWriter, r *http.Request, code string) {
+	if !s.checkKey(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	cur, ok := s.currencyByCode(code)
+	if !ok || cur.Type != "crypto" {
+		writeError(w, http.StatusNotFound, "NotFoundError", "Currency not found")
+		return
+	}
+
+	var errs []FieldError
+	base, ok := s.currencyByCode(q.Get("baseCurrencyCode"))
+	if !ok || base.Type != "fiat" {
+		errs = append(errs, fieldError("baseCurrencyCode", q.Get("baseCurrencyCode"), "isSupported", "baseCurrencyCode must be a supported fiat currency"))
+	}
+	baseAmount, _ := strconv.ParseFloat(q.Get("baseCurrencyAmount"), 64)
+	quoteAmount, _ := strconv.ParseFloat(q.Get("quoteCurrencyAmount"), 64)
+	if (baseAmount > 0) == (quoteAmount > 0) {
+		errs = append(errs, fieldError("baseCurrencyAmount", q.Get("baseCurrencyAmount"), "isExclusive", "exactly one of baseCurrencyAmount or quoteCurrencyAmount must be positive"))
+	}
+	if len(errs) > 0 {
+		writeValidation(w, q, errs...)
+		return
+	}
+
+	rate := s.prices[strings.ToUpper(cur.Code)][strings.ToUpper(base.Code)]
+	if rate == 0 {
+		writeError(w, http.StatusBadRequest, "BadRequestError", "Currency pair is not supported")
+		return
+	}
+
+	extraFee, _ := strconv.ParseFloat(q.Get("extraFeePercentage"), 64)
+	included := q.Get("areFeesIncluded") == "true"
+
+	quote := Quote{
+		BaseCurrency:       base,
+		Currency:           cur,
+		QuoteCurrencyPrice: rate,
+		NetworkFeeAmount:   NetworkFee,
+		ExpiresAt:          s.Now().UTC().Add(QuoteTTL),
+	}
+	if quoteAmount > 0 {
+		quote.QuoteCurrencyAmount = round(quoteAmount, cur.Precision)
+		quote.BaseCurrencyAmount = round(quote.QuoteCurrencyAmount*rate, base.Precision)
+		quote.FeeAmount, quote.ExtraFeeAmount = fees(quote.BaseCurrencyAmount, extraFee, base.Precision)
+	} else {
+		quote.BaseCurrencyAmount = round(baseAmount, base.Precision)
+		quote.FeeAmount, quote.ExtraFeeAmount = fees(quote.BaseCurrencyAmount, extraFee, base.Precision)
+
+		spend := quote.BaseCurrencyAmount
+		if included {
+			spend -= quote.FeeAmount + quote.ExtraFeeAmount + quote.NetworkFeeAmount
+			quote.BaseCurrencyAmount = round(spend, base.Precision)
+		}
+		if spend <= 0 {
+			writeValidation(w, q, fieldError("baseCurrencyAmount", q.Get("baseCurrencyAmount"), "min", "baseCurrencyAmount must be greater than fees"))
+			return
+		}
+		quote.QuoteCurrencyAmount = round(spend/rate, cur.Precision)
+	}
+	quote.TotalAmount = round(quote.BaseCurrencyAmount+quote.FeeAmount+quote.ExtraFeeAmount+quote.NetworkFeeAmount, base.Precision)
+
+	writeJSON(w, http.StatusOK, quote)
+}
+
+func (s *Server) currencyByCode(code string) (Currency, bool) {
+	for _, c := range s.currencies {
+		if strings.EqualFold(c.Code, code) {
+			return c, true
+		}
+	}
+
+	return Currency{}, false
+}
+
+func splitCodes(s string) (codes []string) {
+	for _, code := range strings.Split(s, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, strings.ToUpper(code))
+		}
+	}
+
+	return
+}
+
+//
+// Countries
+//
+
+func (s *Server) ipAddress(w http.ResponseWriter, r *http.Request) {
+	if !s.checkKey(w, r) {
+		return
+	}
+
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	writeJSON(w, http.StatusOK, IPaddress{
+		Alpha2:    "GB",
+		Alpha3:    "GBR",
+		IPaddress: host,
+		IsAllowed: true,
+	})
+}
+
+//
+// Customers
+//
+
+func (s *Server) emailLogin(w http.ResponseWriter, r *http.Request) {
+	if !s.checkKey(w, r) {
+		return
+	}
+
+	var data struct {
+		Email              string `json:"email"`
+		SecurityCode       string `json:"securityCode"`
+		ExternalCustomerID string `json:"externalCustomerId"`
+	}
+	if !readJSON(w, r, &data) {
+		return
+	}
+
+	if !strings.Contains(data.Email, "@") {
+		writeValidation(w, data, fieldError("email", data.Email, "isEmail", "email must be an email"))
+		return
+	}
+
+	if data.SecurityCode == "" {
+		_, exists := s.emails[strings.ToLower(data.Email)]
+		writeJSON(w, http.StatusOK, map[string]bool{"preAuthenticated": exists})
+		return
+	}
+
+	if data.SecurityCode != s.SecurityCode {
+		writeError(w, http.StatusBadRequest, "BadRequestError", "Invalid security code")
+		return
+	}
+
+	c := s.customer(data.Email, data.ExternalCustomerID)
+	writeJSON(w, http.StatusOK, s.auth(c))
+}
+
+func (s *Server) refreshToken(w http.ResponseWriter, r *http.Request) {
+	if !s.checkKey(w, r) {
+		return
+	}
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.auth(c))
+}
+
+func (s *Server) customerInfo(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) updateCustomer(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		FirstName            string   `json:"firstName"`
+		LastName             string   `json:"lastName"`
+		Email                string   `json:"email"`
+		Phone                string   `json:"phoneNumber"`
+		DateOfBirth          string   `json:"dateOfBirth"`
+		SocialSecurityNumber string   `json:"socialSecurityNumber"`
+		DefaultCurrencyID    string   `json:"defaultCurrencyId"`
+		Address              *Address `json:"address"`
+	}
+	if !readJSON(w, r, &data) {
+		return
+	}
+
+	var errs []FieldError
+	if data.Email != "" && !strings.Contains(data.Email, "@") {
+		errs = append(errs, fieldError("email", data.Email, "isEmail", "email must be an email"))
+	}
+	var dob time.Time
+	if data.DateOfBirth != "" {
+		var err error
+		if dob, err = time.Parse("2006-01-02", data.DateOfBirth); err != nil {
+			dob, err = time.Parse(time.RFC3339, data.DateOfBirth)
+		}
+		if err != nil {
+			errs = append(errs, fieldError("dateOfBirth", data.DateOfBirth, "isDateString", "dateOfBirth must be a ISOString"))
+		}
+	}
+	if len(errs) > 0 {
+		writeValidation(w, data, errs...)
+		return
+	}
+
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&c.FirstName, data.FirstName)
+	set(&c.LastName, data.LastName)
+	set(&c.Email, data.Email)
+	set(&c.Phone, data.Phone)
+	set(&c.SocialSecurityNumber, data.SocialSecurityNumber)
+	set(&c.DefaultCurrencyID, data.DefaultCurrencyID)
+	if data.Address != nil {
+		c.Address = *data.Address
+	}
+	if !dob.IsZero() {
+		c.DateOfBirth = &dob
+	}
+	c.UpdatedAt = s.Now().UTC()
+
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) limits(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.customerLimits(c))
+}
+
+func (s *Server) customerLimits(c *Customer) Limits {
+	spent := int(math.Ceil(s.spent[c.ID]))
+	remaining := func(limit int) int {
+		if spent > limit {
+			return 0
+		}
+		return limit - spent
+	}
+
+	return Limits{
+		Limits: []Limit{{
+			Type:                  "buy_credit_debit_card",
+			DailyLimit:            DailyLimit,
+			DailyLimitRemaining:   remaining(DailyLimit),
+			MonthlyLimit:          MonthlyLimit,
+			MonthlyLimitRemaining: remaining(MonthlyLimit),
+		}},
+		VerificationLevels: []VerificationLevel{
+			{Name: "Level 1", Requirements: []Requirement{
+				{Completed: c.FirstName != "" && c.LastName != "", Identifier: "name"},
+				{Completed: c.DateOfBirth != nil, Identifier: "date_of_birth"},
+				{Completed: c.Address.Country != "", Identifier: "address"},
+			}},
+			{Name: "Level 2", Requirements: []Requirement{
+				{Completed: false, Identifier: "identity_document"},
+				{Completed: false, Identifier: "selfie"},
+			}},
+		},
+		LimitIncreaseEligible: true,
+	}
+}
+
+// customer returns existing customer by email or registers a new one
+func (s *Server) customer(email, extid string) *Customer {
+	if id, ok := s.emails[strings.ToLower(email)]; ok {
+		c := s.customers[id]
+		if extid != "" {
+			c.ExternalCustomerID = extid
+		}
+		return c
+	}
+
+	now := s.Now().UTC()
+	eur, _ := s.currencyByCode("eur")
+	c := &Customer{
+		ID:                 uuid.New(),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		Email:              email,
+		LiveMode:           !strings.HasPrefix(s.PublishableKey, "pk_test_"),
+		DefaultCurrencyID:  eur.ID.String(),
+		ExternalCustomerID: extid,
+	}
+	s.customers[c.ID] = c
+	s.emails[strings.ToLower(email)] = c.ID
+
+	return c
+}
+
+func (s *Server) auth(c *Customer) CustomerAuth {
+	return CustomerAuth{
+		CSRFtoken: uuid.New().String(),
+		Token:     s.issueToken(c),
+		Customer:  *c,
+	}
+}
+
+//
+// Files
+//
+
+func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequestError", "Invalid body: "+err.Error())
+		return
+	}
+
+	typ, side, country := r.FormValue("type"), r.FormValue("side"), r.FormValue("country")
+
+	var errs []FieldError
+	switch typ {
+	case "passport", "selfie":
+	case "national_identity_card", "driving_licence":
+		if side != "front" && side != "back" {
+			errs = append(errs, fieldError("side", side, "isIn", "side must be one of the following values: front, back"))
+		}
+	default:
+		errs = append(errs, fieldError("type", typ, "isIn", "type must be one of the following values: passport, national_identity_card, driving_licence, selfie"))
+	}
+	if !s.countryExists(country) {
+		errs = append(errs, fieldError("country", country, "isISO31661Alpha3", "country must be a valid ISO31661 Alpha3 code"))
+	}
+	if _, _, err := r.FormFile("file"); err != nil {
+		errs = append(errs, fieldError("file", nil, "isDefined", "file should not be null or undefined"))
+	}
+	if len(errs) > 0 {
+		writeValidation(w, r.MultipartForm.Value, errs...)
+		return
+	}
+
+	now := s.Now().UTC()
+	f := &File{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Type:       typ,
+		Side:       side,
+		Country:    country,
+		Status:     "pending",
+		CustomerID: c.ID,
+	}
+	f.DownloadLink = s.URL + "/files/" + f.ID.String()
+	s.files[f.ID] = f
+
+	writeJSON(w, http.StatusCreated, f)
+}
+
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	files := []*File{}
+	for _, f := range s.files {
+		if f.CustomerID == c.ID {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.Before(files[j].CreatedAt) })
+
+	writeJSON(w, http.StatusOK, files)
+}
+
+func (s *Server) file(w http.ResponseWriter, r *http.Request, id string) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	fid, _ := uuid.Parse(id)
+	f, ok := s.files[fid]
+	if !ok || f.CustomerID != c.ID {
+		writeError(w, http.StatusNotFound, "NotFoundError", "File not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, f)
+}
+
+func (s *Server) countryExists(alpha3 string) bool {
+	for _, c := range s.countries {
+		if c.Alpha3 == alpha3 {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Tokens
+//
+
+func (s *Server) issueToken(c *Customer) string {
+	now := s.Now()
+	claims, _ := json.Marshal(map[string]interface{}{
+		"sub": c.ID,
+		"jti": uuid.New(),
+		"iat": now.Unix(),
+		"exp": now.Add(s.TokenTTL).Unix(),
+	})
+
+	enc := base64.RawURLEncoding
+	payload := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString(claims)
+
+	return payload + "." + enc.EncodeToString(s.sign(payload))
+}
+
+func (s *Server) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// authorize returns customer of the bearer token, otherwise it writes the
+// unauthorized error
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) (*Customer, bool) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		writeError(w, http.StatusUnauthorized, "UnauthorizedError", "Unauthorized")
+		return nil, false
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, s.sign(parts[0]+"."+parts[1])) {
+		writeError(w, http.StatusUnauthorized, "UnauthorizedError", "Invalid token")
+		return nil, false
+	}
+
+	var claims struct {
+		Sub uuid.UUID
+		Exp int64
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		writeError(w, http.StatusUnauthorized, "UnauthorizedError", "Invalid token")
+		return nil, false
+	}
+	if s.Now().Unix() >= claims.Exp {
+		writeError(w, http.StatusUnauthorized, "UnauthorizedError", "Token expired")
+		return nil, false
+	}
+
+	c, ok := s.customers[claims.Sub]
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UnauthorizedError", "Customer not found")
+		return nil, false
+	}
+
+	return c, true
+}
+
+func (s *Server) checkKey(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Query().Get("apiKey") != s.PublishableKey {
+		writeError(w, http.StatusUnauthorized, "UnauthorizedError", "Invalid API key")
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request) {
+	if !s.checkKey(w, r) {
+		return
+	}
+
+	var data struct {
+		Number     string  `json:"number"`
+		ExpiryDate string  `json:"expiryDate"`
+		CVC        string  `json:"cvc"`
+		Address    Address `json:"address"`
+	}
+	if !readJSON(w, r, &data) {
+		return
+	}
+
+	var errs []FieldError
+	number := strings.Replace(data.Number, " ", "", -1)
+	if !luhn(number) {
+		errs = append(errs, fieldError("number", data.Number, "isCreditCard", "number must be a credit card"))
+	}
+	month, year, ok := parseExpiry(data.ExpiryDate)
+	if !ok {
+		errs = append(errs, fieldError("expiryDate", data.ExpiryDate, "isExpiryDate", "expiryDate must be a valid expiry date"))
+	}
+	if len(data.CVC) < 3 || len(data.CVC) > 4 {
+		errs = append(errs, fieldError("cvc", data.CVC, "length", "cvc must be longer than or equal to 3 characters"))
+	}
+	if len(errs) > 0 {
+		writeValidation(w, data, errs...)
+		return
+	}
+
+	now := s.Now().UTC()
+	t := &Token{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ExpiresAt:      now.Add(time.Hour),
+		ExpiryMonth:    month,
+		ExpiryYear:     year,
+		Brand:          brand(number),
+		Bin:            number[:6],
+		LastDigits:     number[len(number)-4:],
+		BillingAddress: data.Address,
+	}
+	s.tokens[t.ID] = t
+
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// useToken returns the token and deletes it, tokens cannot be used twice
+func (s *Server) useToken(id string) (*Token, bool) {
+	tid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, false
+	}
+
+	t, ok := s.tokens[tid]
+	if !ok || s.Now().After(t.ExpiresAt) {
+		return nil, false
+	}
+	delete(s.tokens, tid)
+
+	return t, true
+}
+
+//
+// Cards
+//
+
+func (s *Server) createCard(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		TokenID string `json:"tokenId"`
+	}
+	if !readJSON(w, r, &data) {
+		return
+	}
+
+	if c.FirstName == "" || c.LastName == "" {
+		writeError(w, http.StatusBadRequest, "BadRequestError", "Customer personal information is missing")
+		return
+	}
+
+	t, ok := s.useToken(data.TokenID)
+	if !ok {
+		writeValidation(w, data, fieldError("tokenId", data.TokenID, "isValidToken", "tokenId must be a valid token"))
+		return
+	}
+
+	now := s.Now().UTC()
+	card := &Card{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ExpiryMonth:    t.ExpiryMonth,
+		ExpiryYear:     t.ExpiryYear,
+		Brand:          t.Brand,
+		Bin:            t.Bin,
+		LastDigits:     t.LastDigits,
+		BillingAddress: t.BillingAddress,
+		CustomerID:     c.ID,
+	}
+	s.cards[card.ID] = card
+
+	writeJSON(w, http.StatusCreated, card)
+}
+
+func (s *Server) listCards(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	cards := []*Card{}
+	for _, card := range s.cards {
+		if card.CustomerID == c.ID {
+			cards = append(cards, card)
+		}
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].CreatedAt.Before(cards[j].CreatedAt) })
+
+	writeJSON(w, http.StatusOK, cards)
+}
+
+func (s *Server) deleteCard(w http.ResponseWriter, r *http.Request, id string) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	card, ok := s.customerCard(c, id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFoundError", "Card not found")
+		return
+	}
+	delete(s.cards, card.ID)
+
+	writeJSON(w, http.StatusOK, card)
+}
+
+func (s *Server) customerCard(c *Customer, id string) (*Card, bool) {
+	cid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, false
+	}
+
+	card, ok := s.cards[cid]
+	if !ok || card.CustomerID != c.ID {
+		return nil, false
+	}
+
+	return card, true
+}
+
+//
+// Transactions
+//
+
+func (s *Server) createTransaction(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		BaseCurrencyAmount float64 `json:"baseCurrencyAmount"`
+		ExtraFeePercentage float64 `json:"extraFeePercentage"`
+		AreFeesIncluded    bool    `json:"areFeesIncluded"`
+		WalletAddress      string  `json:"walletAddress"`
+		WalletAddressTag   string  `json:"walletAddressTag"`
+		BaseCurrencyCode   string  `json:"baseCurrencyCode"`
+		CurrencyCode       string  `json:"currencyCode"`
+		ReturnURL          string  `json:"returnUrl"`
+		TokenID            string  `json:"tokenId"`
+		CardID             string  `json:"cardId"`
+		ExternalID         string  `json:"externalTransactionId"`
+	}
+	if !readJSON(w, r, &data) {
+		return
+	}
+
+	// the repeated request returns the transaction created before
+	if data.ExternalID != "" {
+		if tx, ok := s.externalTransaction(c, data.ExternalID); ok {
+			writeJSON(w, http.StatusOK, tx)
+			return
+		}
+	}
+
+	var errs []FieldError
+	base, ok := s.currencyByCode(data.BaseCurrencyCode)
+	if !ok || base.Type != "fiat" {
+		errs = append(errs, fieldError("baseCurrencyCode", data.BaseCurrencyCode, "isSupported", "baseCurrencyCode must be a supported fiat currency"))
+	}
+	cur, ok := s.currencyByCode(data.CurrencyCode)
+	if !ok || cur.Type != "crypto" || cur.IsSuspended {
+		errs = append(errs, fieldError("currencyCode", data.CurrencyCode, "isSupported", "currencyCode must be a supported cryptocurrency"))
+	} else {
+		regex := cur.AddressRegex
+		if !c.LiveMode {
+			regex = cur.TestnetAddressRegex
+		}
+		if ok, _ := regexp.MatchString(regex, data.WalletAddress); !ok {
+			errs = append(errs, fieldError("walletAddress", data.WalletAddress, "isWalletAddress", "walletAddress must be a valid wallet address"))
+		}
+	}
+	if data.BaseCurrencyAmount < MinAmount {
+		errs = append(errs, fieldError("baseCurrencyAmount", data.BaseCurrencyAmount, "min", fmt.Sprintf("baseCurrencyAmount must not be less than %d", MinAmount)))
+	}
+	if (data.TokenID == "") == (data.CardID == "") {
+		errs = append(errs, fieldError("cardId", data.CardID, "isExclusive", "exactly one of cardId or tokenId must be specified"))
+	}
+	if len(errs) > 0 {
+		writeValidation(w, data, errs...)
+		return
+	}
+
+	var cardID uuid.UUID
+	if data.CardID != "" {
+		card, ok := s.customerCard(c, data.CardID)
+		if !ok {
+			writeError(w, http.StatusNotFound, "NotFoundError", "Card not found")
+			return
+		}
+		cardID = card.ID
+	} else if _, ok := s.useToken(data.TokenID); !ok {
+		writeValidation(w, data, fieldError("tokenId", data.TokenID, "isValidToken", "tokenId must be a valid token"))
+		return
+	}
+
+	rates := s.prices[strings.ToUpper(cur.Code)]
+	eur := data.BaseCurrencyAmount
+	if rate := rates[strings.ToUpper(base.Code)]; rate > 0 && rates["EUR"] > 0 {
+		eur = data.BaseCurrencyAmount / rate * rates["EUR"]
+	}
+	if s.spent[c.ID]+eur > DailyLimit {
+		writeError(w, http.StatusBadRequest, "LimitExceededError", "Transaction amount exceeds the customer limit")
+		return
+	}
+	s.spent[c.ID] += eur
+
+	tx := s.newTransaction(c, base, cur, data.BaseCurrencyAmount, data.ExtraFeePercentage, data.AreFeesIncluded)
+	tx.WalletAddress = data.WalletAddress
+	tx.WalletAddressTag = data.WalletAddressTag
+	tx.ReturnURL = data.ReturnURL
+	tx.RedirectURL = data.ReturnURL
+	tx.CardID = cardID
+	tx.ExternalTransactionID = data.ExternalID
+	s.transactions[tx.ID] = tx
+
+	writeJSON(w, http.StatusCreated, tx)
+}
+
+func (s *Server) newTransaction(c *Customer, base, cur Currency, amount, extraFee float64, feesIncluded bool) *Transaction {
+	rates := s.prices[strings.ToUpper(cur.Code)]
+	rate := rates[strings.ToUpper(base.Code)]
+
+	fee, extra := fees(amount, extraFee, base.Precision)
+	spend := amount
+	if feesIncluded {
+		spend = amount - fee - extra
+	}
+
+	var quote float64
+	if rate > 0 {
+		quote = round(spend/rate, cur.Precision)
+	}
+
+	now := s.Now().UTC()
+	return &Transaction{
+		ID:                  uuid.New(),
+		CreatedAt:           now,
+		UpdatedAt:           now,
+		BaseCurrencyAmount:  amount,
+		QuoteCurrencyAmount: quote,
+		FeeAmount:           fee,
+		ExtraFeeAmount:      extra,
+		AreFeesIncluded:     feesIncluded,
+		Status:              "pending",
+		BaseCurrencyID:      base.ID,
+		CurrencyID:          cur.ID,
+		CustomerID:          c.ID,
+		EURrate:             rates["EUR"],
+		USDrate:             rates["USD"],
+		GBPrate:             rates["GBP"],
+	}
+}
+
+// listTransactions returns transactions of the customer from the newest,
+// filtered by the query: status, startDate, endDate, currencyCode,
+// baseCurrencyCode and externalTransactionId accept comma-separated values,
+// limit and offset select the page. All transactions are returned if the limit
+// is not set.
+func (s *Server) listTransactions(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	var errs []FieldError
+
+	var start, end time.Time
+	for _, v := range []struct {
+		t    *time.Time
+		name string
+	}{{&start, "startDate"}, {&end, "endDate"}} {
+		if q.Get(v.name) == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, q.Get(v.name))
+		if err != nil {
+			errs = append(errs, fieldError(v.name, q.Get(v.name), "isDateString", v.name+" must be a ISO 8601 date string"))
+		}
+		*v.t = t
+	}
+
+	limit, offset := -1, 0
+	for _, v := range []struct {
+		n    *int
+		name string
+	}{{&limit, "limit"}, {&offset, "offset"}} {
+		if q.Get(v.name) == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(q.Get(v.name))
+		if err != nil || n < 0 {
+			errs = append(errs, fieldError(v.name, q.Get(v.name), "min", v.name+" must not be less than 0"))
+		}
+		*v.n = n
+	}
+
+	if len(errs) > 0 {
+		writeValidation(w, q, errs...)
+		return
+	}
+
+	statuses := splitSet(q.Get("status"), nil)
+	currencies := splitSet(q.Get("currencyCode"), strings.ToUpper)
+	bases := splitSet(q.Get("baseCurrencyCode"), strings.ToUpper)
+	external := splitSet(q.Get("externalTransactionId"), nil)
+
+	txs := []*Transaction{}
+	for _, tx := range s.transactions {
+		switch {
+		case tx.CustomerID != c.ID,
+			!start.IsZero() && tx.CreatedAt.Before(start),
+			!end.IsZero() && !tx.CreatedAt.Before(end),
+			!inSet(statuses, tx.Status),
+			!inSet(currencies, s.currencyCode(tx.CurrencyID)),
+			!inSet(bases, s.currencyCode(tx.BaseCurrencyID)),
+			!inSet(external, tx.ExternalTransactionID):
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		if !txs[i].CreatedAt.Equal(txs[j].CreatedAt) {
+			return txs[i].CreatedAt.After(txs[j].CreatedAt)
+		}
+		return txs[i].ID.String() < txs[j].ID.String()
+	})
+
+	if offset > len(txs) {
+		offset = len(txs)
+	}
+	txs = txs[offset:]
+	if limit >= 0 && limit < len(txs) {
+		txs = txs[:limit]
+	}
+
+	writeJSON(w, http.StatusOK, txs)
+}
+
+// currencyCode returns the upper-case code of the currency by ID
+func (s *Server) currencyCode(id uuid.UUID) string {
+	for _, c := range s.currencies {
+		if c.ID == id {
+			return strings.ToUpper(c.Code)
+		}
+	}
+
+	return ""
+}
+
+// splitSet returns the set of comma-separated values, nil if s is empty
+func splitSet(s string, norm func(string) string) map[string]bool {
+	if s == "" {
+		return nil
+	}
+
+	set := make(map[string]bool)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			if norm != nil {
+				v = norm(v)
+			}
+			set[v] = true
+		}
+	}
+
+	return set
+}
+
+// inSet reports whether v is in the set, any value is in the nil set
+func inSet(set map[string]bool, v string) bool {
+	return set == nil || set[v]
+}
+
+func (s *Server) transaction(w http.ResponseWriter, r *http.Request, id string) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	txid, err := uuid.Parse(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "NotFoundError", "Transaction not found")
+		return
+	}
+	tx, ok := s.transactions[txid]
+	if !ok || tx.CustomerID != c.ID {
+		writeError(w, http.StatusNotFound, "NotFoundError", "Transaction not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tx)
+}
+
+func (s *Server) transactionsByExternalID(w http.ResponseWriter, r *http.Request, id string) {
+	c, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	txs := []*Transaction{}
+	if tx, ok := s.externalTransaction(c, id); ok {
+		txs = append(txs, tx)
+	}
+
+	writeJSON(w, http.StatusOK, txs)
+}
+
+func (s *Server) externalTransaction(c *Customer, id string) (*Transaction, bool) {
+	for _, tx := range s.transactions {
+		if tx.CustomerID == c.ID && tx.ExternalTransactionID == id {
+			return tx, true
+		}
+	}
+
+	return nil, false
+}
+
+//
+// Helpers
+//
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequestError", "Invalid body: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, name, message string) {
+	writeJSON(w, status, Error{Message: message, Name: name})
+}
+
+func writeValidation(w http.ResponseWriter, target interface{}, errs ...FieldError) {
+	for i := range errs {
+		errs[i].Target = target
+	}
+
+	writeJSON(w, http.StatusBadRequest, Error{
+		Errors:  errs,
+		Message: "Invalid body",
+		Name:    "BadRequestError",
+	})
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, "NotFoundError", fmt.Sprintf("Cannot %s %s", r.Method, r.URL.Path))
+}
+
+func fieldError(property string, value interface{}, constraint, message string) FieldError {
+	return FieldError{
+		Value:       value,
+		Property:    property,
+		Children:    []interface{}{},
+		Constraints: map[string]string{constraint: message},
+	}
+}
+
+// fees returns the MoonPay fee and the extra fee of the amount
+func fees(amount, extraFeePercentage float64, precision int) (fee, extra float64) {
+	fee = round(math.Max(MinFee, amount*FeePercentage/100), precision)
+	extra = round(amount*extraFeePercentage/100, precision)
+	return
+}
+
+func round(v float64, precision int) float64 {
+	p := math.Pow10(precision)
+	return math.Round(v*p) / p
+}
+
+func luhn(number string) bool {
+	if len(number) < 12 || len(number) > 19 {
+		return false
+	}
+
+	var sum int
+	for i := range number {
+		d := int(number[len(number)-1-i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+
+	return sum%10 == 0
+}
+
+// parseExpiry parses expiry date in the MM/YY or MM/YYYY format
+func parseExpiry(s string) (month, year int, ok bool) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	month, err := strconv.Atoi(parts[0])
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, false
+	}
+	year, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	if year < 100 {
+		year += 2000
+	}
+
+	return month, year, true
+}
+
+func brand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "visa"
+	case strings.HasPrefix(number, "5"):
+		return "mastercard"
+	case strings.HasPrefix(number, "3"):
+		return "american_express"
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,193 @@
+package moonpaytest
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The types below mirror JSON objects of the MoonPay API, they are declared
+// here instead of reusing the moonpay package to produce the same camelCase
+// bodies as the real API does.
+
+type Currency struct {
+	ID                  uuid.UUID `json:"id"`
+	CreatedAt           time.Time `json:"createdAt"`
+	UpdatedAt           time.Time `json:"updatedAt"`
+	Type                string    `json:"type"`
+	Name                string    `json:"name"`
+	Code                string    `json:"code"`
+	Precision           int       `json:"precision"`
+	AddressRegex        string    `json:"addressRegex,omitempty"`
+	TestnetAddressRegex string    `json:"testnetAddressRegex,omitempty"`
+	SupportsAddressTag  bool      `json:"supportsAddressTag"`
+	AddressTagRegex     string    `json:"addressTagRegex,omitempty"`
+	SupportsTestMode    bool      `json:"supportsTestMode"`
+	IsSuspended         bool      `json:"isSuspended"`
+	IsSupportedInUS     bool      `json:"isSupportedInUS"`
+}
+
+type Quote struct {
+	BaseCurrency        Currency  `json:"baseCurrency"`
+	Currency            Currency  `json:"currency"`
+	BaseCurrencyAmount  float64   `json:"baseCurrencyAmount"`
+	QuoteCurrencyAmount float64   `json:"quoteCurrencyAmount"`
+	QuoteCurrencyPrice  float64   `json:"quoteCurrencyPrice"`
+	FeeAmount           float64   `json:"feeAmount"`
+	ExtraFeeAmount      float64   `json:"extraFeeAmount"`
+	NetworkFeeAmount    float64   `json:"networkFeeAmount"`
+	TotalAmount         float64   `json:"totalAmount"`
+	ExpiresAt           time.Time `json:"expiresAt"`
+}
+
+type Country struct {
+	Alpha2             string   `json:"alpha2"`
+	Alpha3             string   `json:"alpha3"`
+	IsAllowed          bool     `json:"isAllowed"`
+	Name               string   `json:"name"`
+	SupportedDocuments []string `json:"supportedDocuments"`
+}
+
+type IPaddress struct {
+	Alpha2    string `json:"alpha2"`
+	Alpha3    string `json:"alpha3"`
+	State     string `json:"state"`
+	IPaddress string `json:"ipAddress"`
+	IsAllowed bool   `json:"isAllowed"`
+}
+
+type Address struct {
+	Street    string `json:"street,omitempty"`
+	SubStreet string `json:"subStreet,omitempty"`
+	Town      string `json:"town,omitempty"`
+	PostCode  string `json:"postCode,omitempty"`
+	State     string `json:"state,omitempty"`
+	Country   string `json:"country,omitempty"`
+}
+
+type Customer struct {
+	ID                    uuid.UUID  `json:"id"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+	FirstName             string     `json:"firstName,omitempty"`
+	LastName              string     `json:"lastName,omitempty"`
+	Email                 string     `json:"email"`
+	Phone                 string     `json:"phoneNumber,omitempty"`
+	IsPhoneNumberVerified bool       `json:"isPhoneNumberVerified"`
+	DateOfBirth           *time.Time `json:"dateOfBirth,omitempty"`
+	SocialSecurityNumber  string     `json:"socialSecurityNumber,omitempty"`
+	LiveMode              bool       `json:"liveMode"`
+	DefaultCurrencyID     string     `json:"defaultCurrencyId,omitempty"`
+	Address               Address    `json:"address"`
+	ExternalCustomerID    string     `json:"externalCustomerId,omitempty"`
+}
+
+type CustomerAuth struct {
+	CSRFtoken string   `json:"csrfToken"`
+	Token     string   `json:"token"`
+	Customer  Customer `json:"customer"`
+}
+
+type Limit struct {
+	Type                  string `json:"type"`
+	DailyLimit            int    `json:"dailyLimit"`
+	DailyLimitRemaining   int    `json:"dailyLimitRemaining"`
+	MonthlyLimit          int    `json:"monthlyLimit"`
+	MonthlyLimitRemaining int    `json:"monthlyLimitRemaining"`
+}
+
+type Requirement struct {
+	Completed  bool   `json:"completed"`
+	Identifier string `json:"identifier"`
+}
+
+type VerificationLevel struct {
+	Name         string        `json:"name"`
+	Requirements []Requirement `json:"requirements"`
+}
+
+type Limits struct {
+	Limits                []Limit             `json:"limits"`
+	VerificationLevels    []VerificationLevel `json:"verificationLevels"`
+	LimitIncreaseEligible bool                `json:"limitIncreaseEligible"`
+}
+
+type File struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	Type         string    `json:"type"`
+	Side         string    `json:"side,omitempty"`
+	Country      string    `json:"country"`
+	DownloadLink string    `json:"downloadLink"`
+	Status       string    `json:"status"`
+	CustomerID   uuid.UUID `json:"customerId"`
+}
+
+type Token struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	ExpiryMonth    int       `json:"expiryMonth"`
+	ExpiryYear     int       `json:"expiryYear"`
+	Brand          string    `json:"brand"`
+	Bin            string    `json:"bin"`
+	LastDigits     string    `json:"lastDigits"`
+	BillingAddress Address   `json:"billingAddress"`
+}
+
+type Card struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	ExpiryMonth    int       `json:"expiryMonth"`
+	ExpiryYear     int       `json:"expiryYear"`
+	Brand          string    `json:"brand"`
+	Bin            string    `json:"bin"`
+	LastDigits     string    `json:"lastDigits"`
+	BillingAddress Address   `json:"billingAddress"`
+	CustomerID     uuid.UUID `json:"customerId"`
+}
+
+type Transaction struct {
+	ID                    uuid.UUID `json:"id"`
+	CreatedAt             time.Time `json:"createdAt"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+	BaseCurrencyAmount    float64   `json:"baseCurrencyAmount"`
+	QuoteCurrencyAmount   float64   `json:"quoteCurrencyAmount"`
+	FeeAmount             float64   `json:"feeAmount"`
+	ExtraFeeAmount        float64   `json:"extraFeeAmount"`
+	AreFeesIncluded       bool      `json:"areFeesIncluded"`
+	Status                string    `json:"status"`
+	FailureReason         string    `json:"failureReason,omitempty"`
+	WalletAddress         string    `json:"walletAddress"`
+	WalletAddressTag      string    `json:"walletAddressTag,omitempty"`
+	CryptoTransactionID   string    `json:"cryptoTransactionId,omitempty"`
+	ExternalTransactionID string    `json:"externalTransactionId,omitempty"`
+	ReturnURL             string    `json:"returnUrl,omitempty"`
+	RedirectURL           string    `json:"redirectUrl,omitempty"`
+	BaseCurrencyID        uuid.UUID `json:"baseCurrencyId"`
+	CurrencyID            uuid.UUID `json:"currencyId"`
+	CustomerID            uuid.UUID `json:"customerId"`
+	CardID                uuid.UUID `json:"cardId"`
+	EURrate               float64   `json:"eurRate"`
+	USDrate               float64   `json:"usdRate"`
+	GBPrate               float64   `json:"gbpRate"`
+}
+
+// FieldError is an element of the validation errors list
+type FieldError struct {
+	Target      interface{}       `json:"target"`
+	Value       interface{}       `json:"value"`
+	Property    string            `json:"property"`
+	Children    []interface{}     `json:"children"`
+	Constraints map[string]string `json:"constraints"`
+}
+
+// Error is a body of the failed response
+type Error struct {
+	Errors  []FieldError `json:"errors,omitempty"`
+	Message string       `json:"message"`
+	Name    string       `json:"name"`
+}
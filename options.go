@@ -0,0 +1,95 @@
+package moonpay
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/goware/urlx"
+)
+
+const (
+	defaultTimeout   = 2 * time.Minute
+	defaultUserAgent = "sg3des/moonpay"
+)
+
+// Option configures the Moonpay client
+type Option func(*Moonpay)
+
+// Logger is used to log performed requests, *log.Logger satisfies it
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// WithBaseURL sets address of the API, it is useful for the sandbox or the
+// test servers, by default it is https://api.moonpay.io/v3
+func WithBaseURL(addr string) Option {
+	return func(m *Moonpay) {
+		u, err := urlx.ParseWithDefaultScheme(addr, "https")
+		if err != nil {
+			m.err = err
+			return
+		}
+
+		m.u = *u
+	}
+}
+
+// WithHTTPClient sets http client used for requests, the client is copied so
+// other options do not modify the passed instance
+func WithHTTPClient(c *http.Client) Option {
+	return func(m *Moonpay) {
+		m.client = c
+	}
+}
+
+// WithTransport sets round tripper of the http client, it allows to configure
+// proxy, TLS or intercept requests
+func WithTransport(rt http.RoundTripper) Option {
+	return func(m *Moonpay) {
+		m.transport = rt
+	}
+}
+
+// WithTimeout sets the time limit for requests, by default it is 2 minutes
+func WithTimeout(d time.Duration) Option {
+	return func(m *Moonpay) {
+		m.timeout = d
+	}
+}
+
+// WithUserAgent sets User-Agent header of requests
+func WithUserAgent(ua string) Option {
+	return func(m *Moonpay) {
+		m.userAgent = ua
+	}
+}
+
+// WithLogger sets logger for requests, by default nothing is logged
+func WithLogger(l Logger) Option {
+	return func(m *Moonpay) {
+		m.logger = l
+	}
+}
+
+// httpClient builds http client from the options
+func (m *Moonpay) httpClient() *http.Client {
+	c := &http.Client{Timeout: defaultTimeout}
+	if m.client != nil {
+		cc := *m.client
+		c = &cc
+	}
+	if m.transport != nil {
+		c.Transport = m.transport
+	}
+	if m.timeout > 0 {
+		c.Timeout = m.timeout
+	}
+
+	return c
+}
+
+func (m *Moonpay) logf(format string, v ...interface{}) {
+	if m.logger != nil {
+		m.logger.Printf(format, v...)
+	}
+}
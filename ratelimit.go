@@ -0,0 +1,210 @@
+package moonpay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateGroup is the group of endpoints sharing the rate limit
+type RateGroup int
+
+const (
+	// RateCatalog is the public endpoints: currencies, prices, countries and
+	// the IP address check
+	RateCatalog RateGroup = iota
+
+	// RateCustomer is the customer endpoints: authentication, profile, files,
+	// tokens and cards
+	RateCustomer
+
+	// RateTransactions is the transaction endpoints
+	RateTransactions
+)
+
+func (g RateGroup) String() string {
+	switch g {
+	case RateCatalog:
+		return "catalog"
+	case RateCustomer:
+		return "customer"
+	case RateTransactions:
+		return "transactions"
+	}
+
+	return "group(" + strconv.Itoa(int(g)) + ")"
+}
+
+// RateLimit configures the token bucket of the endpoints group
+type RateLimit struct {
+	// Rate is the number of requests per second
+	Rate float64
+
+	// Burst is the number of requests allowed to be sent at once
+	Burst int
+}
+
+// WithRateLimit limits requests of the endpoints group. Requests wait for the
+// free slot, but fail fast with ErrRateLimited if the wait exceeds the context
+// deadline. The limit is adapted to the rate limit headers of responses.
+func WithRateLimit(group RateGroup, l RateLimit) Option {
+	return func(m *Moonpay) {
+		if m.limits == nil {
+			m.limits = make(map[RateGroup]*bucket)
+		}
+
+		if l.Rate <= 0 {
+			delete(m.limits, group)
+			return
+		}
+
+		m.limits[group] = newBucket(l.Rate, l.Burst)
+	}
+}
+
+// rateGroup returns the group of the endpoint
+func (m *Moonpay) rateGroup(rawurl string) RateGroup {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return RateCatalog
+	}
+
+	p := strings.TrimPrefix(strings.TrimPrefix(u.Path, m.u.Path), "/")
+	switch strings.SplitN(p, "/", 2)[0] {
+	case "transactions":
+		return RateTransactions
+	case "customers", "files", "tokens", "cards":
+		return RateCustomer
+	}
+
+	return RateCatalog
+}
+
+// limiter returns the bucket of the endpoint or nil if it is not limited
+func (m *Moonpay) limiter(rawurl string) *bucket {
+	if len(m.limits) == 0 {
+		return nil
+	}
+
+	return m.limits[m.rateGroup(rawurl)]
+}
+
+// bucket is the token bucket limiter
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// pausedUntil is set when the server reports exhausted quota
+	pausedUntil time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes the token and returns the delay after which it is available
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.tokens--
+
+	var d time.Duration
+	if b.tokens < 0 {
+		d = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	if pause := b.pausedUntil.Sub(now); pause > d {
+		d = pause
+	}
+
+	return d
+}
+
+// cancel returns the reserved token
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+}
+
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// wait blocks until the token is available
+func (b *bucket) wait(ctx context.Context) error {
+	d := b.reserve(time.Now())
+	if d <= 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		b.cancel()
+		return fmt.Errorf("%w: request would exceed the context deadline", ErrRateLimited)
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// update adapts the bucket to the rate limit headers of the response
+func (b *bucket) update(resp *http.Response, now time.Time) {
+	remaining, err := strconv.Atoi(firstHeader(resp.Header, "X-RateLimit-Remaining", "RateLimit-Remaining"))
+	exhausted := resp.StatusCode == http.StatusTooManyRequests || (err == nil && remaining <= 0)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if err == nil && float64(remaining) < b.tokens {
+		b.tokens = float64(remaining)
+	}
+
+	if exhausted {
+		if d, ok := retryAfter(resp.Header, now); ok {
+			b.pausedUntil = now.Add(d)
+		}
+	}
+}
+
+func firstHeader(h http.Header, keys ...string) string {
+	for _, key := range keys {
+		if v := h.Get(key); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
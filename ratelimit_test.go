@@ -0,0 +1,84 @@
+package moonpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+func TestBucket(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := newBucket(10, 2)
+
+	for i := 0; i < 2; i++ {
+		if d := b.reserve(now); d != 0 {
+			t.Errorf("burst request %d is delayed by %s", i, d)
+		}
+	}
+	if d := b.reserve(now); d != 100*time.Millisecond {
+		t.Errorf("expected delay 100ms, got %s", d)
+	}
+
+	b.update(&http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"2"}},
+	}, now)
+	if d := b.reserve(now); d != 2*time.Second {
+		t.Errorf("expected pause until the reset, got %s", d)
+	}
+}
+
+func TestRateGroup(t *testing.T) {
+	m := New("")
+
+	cases := map[string]RateGroup{
+		m.url("/currencies/btc/price"):    RateCatalog,
+		m.url("/ip_address"):              RateCatalog,
+		m.url("/customers/me"):            RateCustomer,
+		m.url("/cards/%s", "id"):          RateCustomer,
+		m.url("/transactions"):            RateTransactions,
+		m.url("/transactions/%s", "txid"): RateTransactions,
+	}
+
+	for u, group := range cases {
+		if g := m.rateGroup(u); g != group {
+			t.Errorf("%s: expected %s, got %s", u, group, g)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	m := New(srv.PublishableKey,
+		WithBaseURL(srv.APIURL()),
+		WithRateLimit(RateCatalog, RateLimit{Rate: 20, Burst: 1}),
+	)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := m.Countries(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Errorf("requests are not limited, took %s", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := m.CountriesContext(ctx); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected fail fast with ErrRateLimited, got %v", err)
+	}
+
+	// other groups are not limited
+	if _, err := m.Customer(srv.Login("limit@example.com")).Info(); err != nil {
+		t.Error(err)
+	}
+}
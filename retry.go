@@ -0,0 +1,150 @@
+package moonpay
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures retrying of failed requests. Safe GET requests are
+// retried on network errors and 429, 502, 503, 504 responses, other methods
+// are retried only if the idempotency key is attached to the context.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, 1 or less disables retries
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the exponential delay between attempts,
+	// the delay is randomized by the jitter. The request is not retried if the
+	// server asks to wait longer than MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used by the client unless WithRetry is specified
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  200 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+}
+
+// IdempotencyKeyHeader is the header containing the idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithRetry sets the retry policy of the client
+func WithRetry(p RetryPolicy) Option {
+	return func(m *Moonpay) {
+		m.retry = p
+	}
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns the context marking requests as safe to repeat,
+// the key is sent in the Idempotency-Key header of requests other than GET and
+// HEAD
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKey returns the key attached to the context
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// retryable reports whether the failed request may be repeated
+func (p RetryPolicy) retryable(ctx context.Context, method string, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if method != "GET" && method != "HEAD" && IdempotencyKey(ctx) == "" {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// backoff returns the delay before the next attempt, the delay requested by
+// the server in headers takes precedence. It reports false if the server asks
+// to wait longer than MaxBackoff, the request is not retried then.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header, time.Now()); ok {
+			return d, d <= p.MaxBackoff
+		}
+	}
+
+	d := p.MinBackoff << uint(attempt-1)
+	if d > p.MaxBackoff || d <= 0 {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0, true
+	}
+
+	// equal jitter: half of the delay is fixed, half is random
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1)), true
+}
+
+// retryAfter parses Retry-After in seconds or the HTTP date format, and the
+// rate limit reset headers in seconds or the unix time
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := h.Get("Retry-After"); v != "" {
+		if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+			return time.Duration(sec) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(t.Sub(now)), true
+		}
+	}
+
+	for _, key := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		sec, err := strconv.ParseInt(h.Get(key), 10, 64)
+		if err != nil || sec < 0 {
+			continue
+		}
+
+		// large values are the unix time of the reset
+		if sec > 1e9 {
+			return nonNegative(time.Unix(sec, 0).Sub(now)), true
+		}
+		return time.Duration(sec) * time.Second, true
+	}
+
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// sleep waits the duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,121 @@
+package moonpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  time.Millisecond,
+	MaxBackoff:  10 * time.Millisecond,
+}
+
+func TestRetry(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	m := New(srv.PublishableKey, WithBaseURL(srv.APIURL()), WithRetry(testRetryPolicy))
+
+	srv.FailNext(2, http.StatusServiceUnavailable)
+	if _, err := m.Currencies(); err != nil {
+		t.Errorf("GET is not retried: %v", err)
+	}
+
+	srv.FailNext(3, http.StatusBadGateway)
+	if _, err := m.Currencies(); !errors.Is(err, ErrServer) {
+		t.Errorf("expected server error after all attempts, got %v", err)
+	}
+
+	srv.FailNext(1, http.StatusInternalServerError)
+	if _, err := m.Currencies(); !errors.Is(err, ErrServer) {
+		t.Errorf("500 status should not be retried, got %v", err)
+	}
+}
+
+func TestRetryIdempotencyKey(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	m := New(srv.PublishableKey, WithBaseURL(srv.APIURL()), WithRetry(testRetryPolicy))
+
+	srv.FailNext(1, http.StatusTooManyRequests)
+	if _, err := m.SecurityCode("retry@example.com"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("POST without idempotency key should not be retried, got %v", err)
+	}
+
+	ctx := WithIdempotencyKey(context.Background(), "key-1")
+	srv.FailNext(1, http.StatusTooManyRequests)
+	if _, err := m.SecurityCodeContext(ctx, "retry@example.com"); err != nil {
+		t.Errorf("POST with idempotency key is not retried: %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		header http.Header
+		delay  time.Duration
+		ok     bool
+	}{
+		{http.Header{"Retry-After": {"3"}}, 3 * time.Second, true},
+		{http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, time.Minute, true},
+		{http.Header{"X-Ratelimit-Reset": {"10"}}, 10 * time.Second, true},
+		{http.Header{"Ratelimit-Reset": {"1559390430"}}, 30 * time.Second, true},
+		{http.Header{}, 0, false},
+	}
+
+	for _, c := range cases {
+		d, ok := retryAfter(c.header, now)
+		if d != c.delay || ok != c.ok {
+			t.Errorf("%v: expected %s %t, got %s %t", c.header, c.delay, c.ok, d, ok)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt := 1; attempt < 10; attempt++ {
+		d, ok := p.backoff(attempt, nil)
+		if !ok || d < p.MinBackoff/2 || d > p.MaxBackoff {
+			t.Errorf("attempt %d: backoff %s is out of bounds", attempt, d)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"1"}}}
+	if d, ok := p.backoff(1, resp); !ok || d != time.Second {
+		t.Errorf("unexpected backoff %s %t of Retry-After", d, ok)
+	}
+	resp.Header.Set("Retry-After", "3600")
+	if _, ok := p.backoff(1, resp); ok {
+		t.Error("Retry-After longer than MaxBackoff is accepted")
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	var attempts int
+	m := New("pk_test_key", WithRetry(testRetryPolicy), WithTransport(transportFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": {"3600"}},
+			Body:       http.NoBody,
+			Request:    r,
+		}, nil
+	})))
+
+	start := time.Now()
+	if _, err := m.Currencies(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected rate limited error, got %v", err)
+	}
+	if attempts != 1 || time.Since(start) > time.Second {
+		t.Errorf("request is retried %d times in %s", attempts, time.Since(start))
+	}
+}
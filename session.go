@@ -0,0 +1,317 @@
+package moonpay
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSessionNotFound is returned by the SessionStore if the session of the
+	// customer does not exist
+	ErrSessionNotFound = errors.New("moonpay: session not found")
+
+	// ErrNoSessionStore is returned by CustomerByID if the store is not set
+	ErrNoSessionStore = errors.New("moonpay: session store is not configured")
+)
+
+// Session is the stored authentication of the customer
+type Session struct {
+	CustomerID         uuid.UUID
+	ExternalCustomerID string
+	Token              string
+	UpdatedAt          time.Time
+}
+
+// NewSession returns session of the authenticated customer
+func NewSession(auth CustomerAuth) Session {
+	return Session{
+		CustomerID:         auth.Customer.ID,
+		ExternalCustomerID: auth.Customer.ExternalCustomerID,
+		Token:              auth.Token,
+		UpdatedAt:          time.Now(),
+	}
+}
+
+// SessionStore persists sessions of the customers. Get and Delete accept
+// either the customer ID or the external customer ID.
+type SessionStore interface {
+	Get(ctx context.Context, id string) (Session, error)
+	Put(ctx context.Context, s Session) error
+	Delete(ctx context.Context, id string) error
+}
+
+// WithSessionStore sets the store of customer sessions used by CustomerByID
+func WithSessionStore(s SessionStore) Option {
+	return func(m *Moonpay) {
+		m.sessions = s
+	}
+}
+
+// CustomerByID loads the session of the customer from the store, refreshes its
+// token and writes it back. The id is the customer ID or the external customer
+// ID. Tokens refreshed later are written to the store as well.
+func (m *Moonpay) CustomerByID(id string) (*MoonpayCustomer, error) {
+	return m.CustomerByIDContext(context.Background(), id)
+}
+
+// CustomerByIDContext is like CustomerByID but with the context.
+func (m *Moonpay) CustomerByIDContext(ctx context.Context, id string) (*MoonpayCustomer, error) {
+	if m.sessions == nil {
+		return nil, ErrNoSessionStore
+	}
+
+	s, err := m.sessions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c := m.Customer(s.Token)
+	c.OnTokenRefresh(func(auth CustomerAuth) {
+		s := NewSession(auth)
+		if err := m.sessions.Put(context.Background(), s); err != nil {
+			m.logf("moonpay: failed store session of %s: %v", s.CustomerID, err)
+		}
+	})
+
+	if _, err := c.RefreshTokenContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+//
+// Memory
+//
+
+// MemoryStore is the SessionStore keeping sessions in memory
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]Session
+	external map[string]uuid.UUID
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[uuid.UUID]Session),
+		external: make(map[string]uuid.UUID),
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sess, ok := s.sessions[s.lookup(id)]
+	if !ok {
+		return sess, ErrSessionNotFound
+	}
+
+	return sess, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, sess Session) error {
+	if sess.CustomerID == uuid.Nil {
+		return errors.New("moonpay: session without customer ID")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// keep the external ID if the refreshed session does not contain it
+	if old, ok := s.sessions[sess.CustomerID]; ok && sess.ExternalCustomerID == "" {
+		sess.ExternalCustomerID = old.ExternalCustomerID
+	}
+
+	s.sessions[sess.CustomerID] = sess
+	if sess.ExternalCustomerID != "" {
+		s.external[sess.ExternalCustomerID] = sess.CustomerID
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cid := s.lookup(id)
+	sess, ok := s.sessions[cid]
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	delete(s.sessions, cid)
+	delete(s.external, sess.ExternalCustomerID)
+
+	return nil
+}
+
+// list returns all sessions
+func (s *MemoryStore) list() []Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		list = append(list, sess)
+	}
+
+	return list
+}
+
+// lookup returns customer ID by the customer ID or the external ID
+func (s *MemoryStore) lookup(id string) uuid.UUID {
+	if cid, ok := s.external[id]; ok {
+		return cid
+	}
+
+	cid, _ := uuid.Parse(id)
+	return cid
+}
+
+//
+// File
+//
+
+// FileStore is the SessionStore keeping sessions in the file encrypted by
+// AES-GCM, the whole file is rewritten on each change.
+type FileStore struct {
+	*MemoryStore
+
+	path string
+	aead cipher.AEAD
+
+	// mu serializes writing of the file
+	mu sync.Mutex
+}
+
+// NewFileStore opens the store in the file, the key must be 16, 24 or 32 bytes
+// long to select AES-128, AES-192 or AES-256. The file is created on the first
+// write if it does not exist.
+func NewFileStore(path string, key []byte) (*FileStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileStore{
+		MemoryStore: NewMemoryStore(),
+		path:        path,
+		aead:        aead,
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	if err := s.decrypt(data, &sessions); err != nil {
+		return nil, fmt.Errorf("moonpay: failed read sessions file %s: %w", path, err)
+	}
+	for _, sess := range sessions {
+		s.MemoryStore.Put(context.Background(), sess)
+	}
+
+	return s, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, sess Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.MemoryStore.Put(ctx, sess); err != nil {
+		return err
+	}
+
+	return s.save()
+}
+
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.MemoryStore.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return s.save()
+}
+
+// save writes sessions to the temporary file and renames it, so the file is
+// never left partially written
+func (s *FileStore) save() error {
+	data, err := s.encrypt(s.list())
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileStore) encrypt(v interface{}) ([]byte, error) {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return s.aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+func (s *FileStore) decrypt(data []byte, v interface{}) error {
+	n := s.aead.NonceSize()
+	if len(data) < n {
+		return errors.New("file is too short")
+	}
+
+	plain, err := s.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(plain, v)
+}
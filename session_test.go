@@ -0,0 +1,100 @@
+package moonpay
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+var testSessionKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestMemoryStore(t *testing.T) {
+	testSessionStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions")
+
+	s, err := NewFileStore(path, testSessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSessionStore(t, s)
+
+	ctx := context.Background()
+	sess := Session{CustomerID: uuid.New(), ExternalCustomerID: "ext-2", Token: "token-2"}
+	if err := s.Put(ctx, sess); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(path, testSessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.Get(ctx, "ext-2"); err != nil || got.Token != "token-2" {
+		t.Errorf("session is not persisted: %+v, %v", got, err)
+	}
+
+	if _, err := NewFileStore(path, []byte("fedcba9876543210fedcba9876543210")); err == nil {
+		t.Error("file is decrypted by the wrong key")
+	}
+}
+
+func testSessionStore(t *testing.T, s SessionStore) {
+	ctx := context.Background()
+	sess := Session{CustomerID: uuid.New(), ExternalCustomerID: "ext-1", Token: "token-1"}
+
+	if _, err := s.Get(ctx, "ext-1"); err != ErrSessionNotFound {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	if err := s.Put(ctx, sess); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{sess.CustomerID.String(), "ext-1"} {
+		got, err := s.Get(ctx, id)
+		if err != nil || got.Token != "token-1" {
+			t.Errorf("session is not found by %s: %+v, %v", id, got, err)
+		}
+	}
+
+	if err := s.Delete(ctx, "ext-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, sess.CustomerID.String()); err != ErrSessionNotFound {
+		t.Errorf("session is not deleted: %v", err)
+	}
+}
+
+func TestCustomerByID(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	m := New(srv.PublishableKey, WithBaseURL(srv.APIURL()), WithSessionStore(store))
+
+	auth, err := m.ConfirmRegistration("session@example.com", srv.SecurityCode, "ext-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(context.Background(), NewSession(auth)); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := m.CustomerByID("ext-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Token() == auth.Token {
+		t.Error("token is not refreshed")
+	}
+
+	sess, err := store.Get(context.Background(), auth.Customer.ID.String())
+	if err != nil || sess.Token != c.Token() {
+		t.Errorf("refreshed token is not stored: %+v, %v", sess, err)
+	}
+}
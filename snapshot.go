@@ -0,0 +1,47 @@
+package moonpay
+
+import (
+	_ "embed"
+	"encoding/json"
+	"time"
+)
+
+//go:generate go run ./internal/snapshotgen -o snapshot/catalog.json
+
+// SnapshotVersion is the format version of the catalog snapshot
+const SnapshotVersion = 1
+
+//go:embed snapshot/catalog.json
+var snapshotData []byte
+
+// fakeSource is the source of snapshots generated from the fake server, they
+// are never served
+const fakeSource = "moonpaytest"
+
+// Snapshot is the copy of the catalog embedded in the package. The committed
+// snapshot is empty, it is generated from the live API by go generate with
+// MOONPAY_KEY set and then the catalog falls back to it if the API is
+// unavailable on the first lookup.
+type Snapshot struct {
+	Version     int
+	GeneratedAt time.Time
+
+	// Source is the address of the API the snapshot is generated from
+	Source string
+
+	Currencies []Currency
+	Countries  []Country
+}
+
+// EmbeddedSnapshot returns the catalog snapshot shipped with the package
+func EmbeddedSnapshot() (Snapshot, error) {
+	var s Snapshot
+	err := json.Unmarshal(snapshotData, &s)
+	return s, err
+}
+
+// valid reports whether the snapshot may be served: it is of the current
+// version, not empty and generated from the live API
+func (s Snapshot) valid() bool {
+	return s.Version == SnapshotVersion && s.Source != "" && s.Source != fakeSource && len(s.Currencies) > 0
+}
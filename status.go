@@ -0,0 +1,105 @@
+package moonpay
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TransactionStatus is the state of the transaction
+// https://www.moonpay.io/api_reference/v3#transaction_object
+type TransactionStatus string
+
+const (
+	// StatusWaitingPayment is the transaction waiting for the bank transfer
+	StatusWaitingPayment TransactionStatus = "waitingPayment"
+
+	// StatusPending is the transaction being processed
+	StatusPending TransactionStatus = "pending"
+
+	// StatusWaitingAuthorization is the transaction waiting for the 3D Secure
+	// authorization of the card payment by the customer
+	StatusWaitingAuthorization TransactionStatus = "waitingAuthorization"
+
+	// StatusCompleted is the transaction with cryptocurrency sent to the wallet
+	StatusCompleted TransactionStatus = "completed"
+
+	// StatusFailed is the failed transaction, see FailureReason
+	StatusFailed TransactionStatus = "failed"
+)
+
+// FailureReason describes why the transaction has failed. The API may return
+// reasons not listed in the constants.
+type FailureReason string
+
+const (
+	FailureCardDeclined        FailureReason = "cardDeclined"
+	FailureInsufficientFunds   FailureReason = "insufficientFunds"
+	FailureAuthorizationFailed FailureReason = "authorizationFailed"
+	FailurePaymentTimeout      FailureReason = "paymentTimeout"
+	FailureRejected            FailureReason = "rejected"
+)
+
+var (
+	// ErrInvalidTransition is returned by ValidateTransition if the status
+	// cannot change to the next one, e.g. the completed transaction fails
+	ErrInvalidTransition = errors.New("moonpay: invalid transaction status transition")
+
+	// ErrStaleTransaction is returned by ValidateTransition if the next
+	// snapshot is older than the previous one
+	ErrStaleTransaction = errors.New("moonpay: stale transaction snapshot")
+)
+
+// statusRank orders statuses of the lifecycle, the status never changes to
+// the one with the lower rank
+var statusRank = map[TransactionStatus]int{
+	StatusWaitingPayment:       0,
+	StatusPending:              1,
+	StatusWaitingAuthorization: 1,
+	StatusCompleted:            2,
+	StatusFailed:               2,
+}
+
+// IsKnown reports whether the status is one of the constants
+func (s TransactionStatus) IsKnown() bool {
+	_, ok := statusRank[s]
+	return ok
+}
+
+// IsTerminal reports whether the status is final: completed or failed
+func (s TransactionStatus) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusFailed
+}
+
+// CanTransitionTo reports whether the status may change to the next one. The
+// transaction moves from waitingPayment to pending and waitingAuthorization,
+// which may alternate, and ends up completed or failed. The unchanged status
+// is allowed.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	if s == next {
+		return true
+	}
+	if s.IsTerminal() || !next.IsKnown() {
+		return false
+	}
+
+	rank, ok := statusRank[s]
+	return ok && statusRank[next] >= rank
+}
+
+// ValidateTransition checks the next snapshot of the transaction observed
+// after the previous one, e.g. received by the webhook or polling. It returns
+// ErrStaleTransaction if the next snapshot is older, and ErrInvalidTransition
+// if the status cannot change so.
+func ValidateTransition(prev, next Transaction) error {
+	if prev.ID != next.ID {
+		return fmt.Errorf("moonpay: snapshots of different transactions %s and %s", prev.ID, next.ID)
+	}
+	if next.UpdatedAt.Before(prev.UpdatedAt) {
+		return fmt.Errorf("%w: %s updated at %s, previous at %s", ErrStaleTransaction, next.ID, next.UpdatedAt, prev.UpdatedAt)
+	}
+	if !prev.Status.CanTransitionTo(next.Status) {
+		return fmt.Errorf("%w: %s from %s to %s", ErrInvalidTransition, next.ID, prev.Status, next.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package moonpay
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTransactionStatus(t *testing.T) {
+	cases := []struct {
+		from, to TransactionStatus
+		ok       bool
+	}{
+		{StatusWaitingPayment, StatusPending, true},
+		{StatusWaitingPayment, StatusFailed, true},
+		{StatusPending, StatusWaitingAuthorization, true},
+		{StatusWaitingAuthorization, StatusPending, true},
+		{StatusPending, StatusCompleted, true},
+		{StatusPending, StatusPending, true},
+		{StatusPending, StatusWaitingPayment, false},
+		{StatusCompleted, StatusFailed, false},
+		{StatusFailed, StatusPending, false},
+		{StatusPending, "unknown", false},
+	}
+
+	for _, c := range cases {
+		if ok := c.from.CanTransitionTo(c.to); ok != c.ok {
+			t.Errorf("%s -> %s: expected %v, got %v", c.from, c.to, c.ok, ok)
+		}
+	}
+
+	if StatusPending.IsTerminal() || !StatusCompleted.IsTerminal() || !StatusFailed.IsTerminal() {
+		t.Error("unexpected terminal statuses")
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	now := time.Now()
+	prev := Transaction{ID: uuid.New(), Status: StatusPending, UpdatedAt: now}
+
+	next := prev
+	next.Status, next.UpdatedAt = StatusCompleted, now.Add(time.Second)
+	if err := ValidateTransition(prev, next); err != nil {
+		t.Error(err)
+	}
+
+	// the completed transaction cannot fail
+	failed := next
+	failed.Status, failed.UpdatedAt = StatusFailed, now.Add(2*time.Second)
+	if err := ValidateTransition(next, failed); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("expected invalid transition, got %v", err)
+	}
+
+	// the webhook delivered late
+	if err := ValidateTransition(next, prev); !errors.Is(err, ErrStaleTransaction) {
+		t.Errorf("expected stale transaction, got %v", err)
+	}
+
+	other := next
+	other.ID = uuid.New()
+	if err := ValidateTransition(prev, other); err == nil {
+		t.Error("expected error for different transactions")
+	}
+}
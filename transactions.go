@@ -0,0 +1,184 @@
+package moonpay
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imroc/req"
+)
+
+// DefaultTransactionsLimit is the page size of the TransactionScanner if the
+// limit of the filter is not set
+const DefaultTransactionsLimit = 50
+
+// TransactionFilter selects transactions of the customer, zero fields are not
+// applied. Transactions are ordered from the newest.
+type TransactionFilter struct {
+	// Status selects transactions with any of the statuses
+	Status []TransactionStatus
+
+	// From and To select transactions created since From and before To
+	From time.Time
+	To   time.Time
+
+	// CurrencyCode and BaseCurrencyCode select transactions of the
+	// cryptocurrency and the fiat currency
+	CurrencyCode     string
+	BaseCurrencyCode string
+
+	// ExternalTransactionIDs selects transactions with any of the IDs
+	ExternalTransactionIDs []string
+
+	// Limit and Offset select the page, Limit is the page size of the
+	// TransactionScanner
+	Limit  int
+	Offset int
+}
+
+func (f TransactionFilter) params() req.QueryParam {
+	params := req.QueryParam{}
+
+	if len(f.Status) > 0 {
+		ss := make([]string, len(f.Status))
+		for i, s := range f.Status {
+			ss[i] = string(s)
+		}
+		params["status"] = strings.Join(ss, ",")
+	}
+	if !f.From.IsZero() {
+		params["startDate"] = f.From.UTC().Format(time.RFC3339Nano)
+	}
+	if !f.To.IsZero() {
+		params["endDate"] = f.To.UTC().Format(time.RFC3339Nano)
+	}
+	if f.CurrencyCode != "" {
+		params["currencyCode"] = strings.ToLower(f.CurrencyCode)
+	}
+	if f.BaseCurrencyCode != "" {
+		params["baseCurrencyCode"] = strings.ToLower(f.BaseCurrencyCode)
+	}
+	if len(f.ExternalTransactionIDs) > 0 {
+		params["externalTransactionId"] = strings.Join(f.ExternalTransactionIDs, ",")
+	}
+	if f.Limit > 0 {
+		params["limit"] = f.Limit
+	}
+	if f.Offset > 0 {
+		params["offset"] = f.Offset
+	}
+
+	return params
+}
+
+// ListTransactions returns the page of the logged-in customer's transactions
+// selected by the filter
+// https://www.moonpay.io/api_reference/v3#list_transactions
+func (m *MoonpayCustomer) ListTransactions(f TransactionFilter) ([]Transaction, error) {
+	return m.ListTransactionsContext(context.Background(), f)
+}
+
+// ListTransactionsContext is like ListTransactions but with the context.
+func (m *MoonpayCustomer) ListTransactionsContext(ctx context.Context, f TransactionFilter) (txs []Transaction, err error) {
+	resp, err := m.do(ctx, "GET", m.url("/transactions"), f.params())
+	if err != nil {
+		return nil, err
+	}
+
+	err = resp.ToJSON(&txs)
+	return
+}
+
+// TransactionScanner reads transactions selected by the filter page by page,
+// the next page is requested when the current one is read:
+//
+//	s := customer.ScanTransactions(moonpay.TransactionFilter{Status: []moonpay.TransactionStatus{moonpay.StatusCompleted}})
+//	for s.Scan() {
+//		tx := s.Transaction()
+//	}
+//	if err := s.Err(); err != nil {...}
+//
+// Only one page is kept in memory. Transactions created after the first page
+// are not returned, so they do not shift pages. Transactions may be skipped if
+// their status changes while scanning by the status filter.
+type TransactionScanner struct {
+	ctx    context.Context
+	m      *MoonpayCustomer
+	filter TransactionFilter
+
+	page []Transaction
+	tx   Transaction
+	done bool
+
+	// prev is IDs of the previous page, transactions moved to the next page
+	// by the change of the status are skipped
+	prev map[uuid.UUID]bool
+	err  error
+}
+
+// ScanTransactions returns the scanner of the logged-in customer's
+// transactions selected by the filter
+func (m *MoonpayCustomer) ScanTransactions(f TransactionFilter) *TransactionScanner {
+	return m.ScanTransactionsContext(context.Background(), f)
+}
+
+// ScanTransactionsContext is like ScanTransactions but with the context.
+func (m *MoonpayCustomer) ScanTransactionsContext(ctx context.Context, f TransactionFilter) *TransactionScanner {
+	if f.Limit <= 0 {
+		f.Limit = DefaultTransactionsLimit
+	}
+
+	return &TransactionScanner{ctx: ctx, m: m, filter: f}
+}
+
+// Scan advances to the next transaction, it returns false when there are no
+// more transactions or the request fails
+func (s *TransactionScanner) Scan() bool {
+	for len(s.page) == 0 {
+		if s.done || s.err != nil {
+			return false
+		}
+		s.fetch()
+	}
+
+	s.tx, s.page = s.page[0], s.page[1:]
+	return true
+}
+
+// Transaction returns the current transaction
+func (s *TransactionScanner) Transaction() Transaction {
+	return s.tx
+}
+
+// Err returns the error of the request, if any
+func (s *TransactionScanner) Err() error {
+	return s.err
+}
+
+// fetch requests the next page
+func (s *TransactionScanner) fetch() {
+	page, err := s.m.ListTransactionsContext(s.ctx, s.filter)
+	if err != nil {
+		s.err = err
+		return
+	}
+
+	// pin the end of the range by the newest transaction
+	if s.filter.To.IsZero() && s.filter.Offset == 0 && len(page) > 0 {
+		s.filter.To = page[0].CreatedAt.Add(time.Nanosecond)
+	}
+
+	s.done = len(page) < s.filter.Limit
+	s.filter.Offset += len(page)
+
+	ids := make(map[uuid.UUID]bool, len(page))
+	s.page = page[:0]
+	for _, tx := range page {
+		ids[tx.ID] = true
+		if !s.prev[tx.ID] {
+			s.page = append(s.page, tx)
+		}
+	}
+	s.prev = ids
+}
@@ -0,0 +1,178 @@
+package moonpay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+// createTransactions creates n transactions an hour apart from start, every
+// third is in GBP and every second is completed
+func createTransactions(t *testing.T, srv *moonpaytest.Server, m *Moonpay, customer *MoonpayCustomer, start time.Time, n int) []Transaction {
+	t.Helper()
+
+	var now time.Time
+	srv.Now = func() time.Time { return now }
+	defer func() { srv.Now = time.Now }()
+
+	var txs []Transaction
+	for i := 0; i < n; i++ {
+		now = start.Add(time.Duration(i) * time.Hour)
+
+		token, err := m.CreateToken(TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fiat := "eur"
+		if i%3 == 2 {
+			fiat = "gbp"
+		}
+		tx, err := customer.CreateTransaction(TransactionRequest{
+			BaseCurrencyAmount:    NewAmount(20, 0),
+			BaseCurrencyCode:      fiat,
+			CurrencyCode:          "btc",
+			WalletAddress:         "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+			TokenID:               token.ID.String(),
+			ExternalTransactionID: fmt.Sprintf("order-%d", i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 1 {
+			if err := srv.SetTransactionStatus(tx.ID, string(StatusCompleted), ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		txs = append(txs, tx)
+	}
+
+	return txs
+}
+
+func TestListTransactions(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	m := New(srv.PublishableKey, WithBaseURL(srv.APIURL()))
+	customer := m.Customer(srv.Login("list@example.com"))
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	txs := createTransactions(t, srv, m, customer, start, 7)
+
+	for _, c := range []struct {
+		name     string
+		filter   TransactionFilter
+		expected []int
+	}{
+		{"all", TransactionFilter{}, []int{6, 5, 4, 3, 2, 1, 0}},
+		{"page", TransactionFilter{Limit: 3, Offset: 2}, []int{4, 3, 2}},
+		{"last page", TransactionFilter{Limit: 3, Offset: 6}, []int{0}},
+		{"status", TransactionFilter{Status: []TransactionStatus{StatusCompleted}}, []int{5, 3, 1}},
+		{"dates", TransactionFilter{From: start.Add(2 * time.Hour), To: start.Add(4 * time.Hour)}, []int{3, 2}},
+		{"fiat", TransactionFilter{BaseCurrencyCode: "GBP"}, []int{5, 2}},
+		{"crypto", TransactionFilter{CurrencyCode: "eth"}, nil},
+		{"external", TransactionFilter{ExternalTransactionIDs: []string{"order-0", "order-4", "order-9"}}, []int{4, 0}},
+	} {
+		list, err := customer.ListTransactions(c.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		if len(list) != len(c.expected) {
+			t.Errorf("%s: %d transactions, expected %d", c.name, len(list), len(c.expected))
+			continue
+		}
+		for i, tx := range list {
+			if expected := txs[c.expected[i]]; tx.ID != expected.ID {
+				t.Errorf("%s: transaction %d is %s, expected %s", c.name, i, tx.ExternalTransactionID, expected.ExternalTransactionID)
+			}
+		}
+	}
+}
+
+func TestScanTransactions(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	var requests int
+	m := New(srv.PublishableKey,
+		WithBaseURL(srv.APIURL()),
+		WithTransport(transportFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method == "GET" && r.URL.Path == "/v3/transactions" {
+				requests++
+			}
+			return http.DefaultTransport.RoundTrip(r)
+		})),
+	)
+	customer := m.Customer(srv.Login("scan@example.com"))
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	txs := createTransactions(t, srv, m, customer, start, 7)
+
+	s := customer.ScanTransactionsContext(context.Background(), TransactionFilter{Limit: 2})
+
+	// the page is requested lazily
+	if requests != 0 {
+		t.Errorf("%d requests before Scan", requests)
+	}
+
+	var n int
+	for s.Scan() {
+		if n == 0 {
+			// the transaction created while scanning is not returned
+			createTransactions(t, srv, m, customer, start.Add(24*time.Hour), 1)
+		}
+
+		if tx := s.Transaction(); tx.ID != txs[len(txs)-1-n].ID {
+			t.Errorf("transaction %d is %s", n, tx.ExternalTransactionID)
+		}
+		n++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != len(txs) {
+		t.Errorf("scanned %d transactions, expected %d", n, len(txs))
+	}
+	if requests != 4 {
+		t.Errorf("%d pages are requested, expected 4", requests)
+	}
+
+	// statuses are camelCase
+	for _, i := range []int{0, 4} {
+		if err := srv.SetTransactionStatus(txs[i].ID, string(StatusWaitingAuthorization), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s = customer.ScanTransactions(TransactionFilter{Status: []TransactionStatus{StatusWaitingAuthorization}, Limit: 1})
+	var found []string
+	for s.Scan() {
+		found = append(found, s.Transaction().ExternalTransactionID)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0] != "order-4" || found[1] != "order-0" {
+		t.Errorf("unexpected transactions %v waiting authorization", found)
+	}
+}
+
+func TestScanTransactionsError(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	m := New(srv.PublishableKey, WithBaseURL(srv.APIURL()))
+	s := m.Customer("invalid").ScanTransactions(TransactionFilter{})
+	if s.Scan() {
+		t.Error("transaction is scanned with invalid token")
+	}
+	if s.Err() == nil {
+		t.Error("expected error")
+	}
+}
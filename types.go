@@ -25,6 +25,25 @@ type Currency struct {
 	IsSupportedInUS     bool
 }
 
+// Quote is the estimation of the purchase, amounts are in the base (fiat)
+// currency except QuoteCurrencyAmount. TotalAmount is the sum charged from the
+// customer including all fees.
+// https://www.moonpay.io/api_reference/v3#get_currency_quote
+type Quote struct {
+	BaseCurrency Currency
+	Currency     Currency
+
+	BaseCurrencyAmount  Amount `json:"baseCurrencyAmount"`
+	QuoteCurrencyAmount Amount `json:"quoteCurrencyAmount"`
+	QuoteCurrencyPrice  Amount `json:"quoteCurrencyPrice"`
+	FeeAmount           Amount `json:"feeAmount"`
+	ExtraFeeAmount      Amount `json:"extraFeeAmount"`
+	NetworkFeeAmount    Amount `json:"networkFeeAmount"`
+	TotalAmount         Amount `json:"totalAmount"`
+
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
 // Country objects represent the countries supported by MoonPay. If the isAllowed
 // flag is set to false, it means that MoonPay accepts citizens of this country
 // but not residents.
@@ -44,9 +63,26 @@ const (
 	DocumentSelfie         = "selfie"
 
 	SideFront = "front"
-	SideBack
+	SideBack  = "back"
 )
 
+// File objects represent identity documents uploaded by the customer.
+// Status is the review state of the document: pending, approved or rejected.
+// https://www.moonpay.io/api_reference/v3#file_object
+type File struct {
+	ID        uuid.UUID `json:"id,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+
+	Type         string
+	Side         string
+	Country      string
+	DownloadLink string
+	Status       string
+
+	CustomerID uuid.UUID
+}
+
 // IP address objects represent the end user's IP address. If the isAllowed flag
 // is set to false, it means that MoonPay accepts citizens of this country but
 // not residents.
@@ -181,26 +217,32 @@ type TokenRequest struct {
 
 // Transaction objects represent cryptocurrency purchases by your end users.
 // Cryptocurrency purchases and withdrawals are performed asynchronously.
-// You must set up a webhook to be notified of a status change.
+// You must set up a webhook to be notified of a status change, see
+// WebhookHandler.
 // https://www.moonpay.io/api_reference/v3#transaction_object
 type Transaction struct {
 	ID        uuid.UUID `json:"id,omitempty"`
 	CreatedAt time.Time `json:"createdAt,omitempty"`
 	UpdatedAt time.Time `json:"updatedAt,omitempty"`
 
-	BaseCurrencyAmount  float64 `json:"baseCurrencyAmount"`
-	QuoteCurrencyAmount float64 `json:"quoteCurrencyAmount"`
-	FeeAmount           float64 `json:"feeAmount"`
-	ExtraFeeAmount      float64 `json:"extraFeePercentage"`
-	AreFeesIncluded     bool    `json:"areFeesIncluded"`
+	BaseCurrencyAmount  Amount `json:"baseCurrencyAmount"`
+	QuoteCurrencyAmount Amount `json:"quoteCurrencyAmount"`
+	FeeAmount           Amount `json:"feeAmount"`
+	// ExtraFeeAmount is the partner fee in the base currency, it is computed
+	// from TransactionRequest.ExtraFeeAmount sent as extraFeePercentage
+	ExtraFeeAmount  Amount `json:"extraFeeAmount"`
+	AreFeesIncluded bool   `json:"areFeesIncluded"`
 
-	Status        string
-	FailureReason string
+	Status        TransactionStatus `json:"status"`
+	FailureReason FailureReason     `json:"failureReason,omitempty"`
 
 	WalletAddress       string `json:"walletAddress"`
 	WalletAddressTag    string `json:"walletAddressTag,omitempty"`
 	CryptoTransactionId string
 
+	// ExternalTransactionID is the identifier assigned by the partner
+	ExternalTransactionID string `json:"externalTransactionId,omitempty"`
+
 	ReturnURL   string `json:"returnUrl,omitempty"`
 	RedirectURL string `json:"redirectUrl,omitempty"`
 
@@ -209,15 +251,17 @@ type Transaction struct {
 	CustomerID     uuid.UUID `json:"customerId"`
 	CardID         uuid.UUID `json:"cardId"`
 
-	EURrate float64 `json:"eurRate"`
-	USDrate float64 `json:"usdRate"`
-	GBPrate float64 `json:"gbpRate"`
+	EURrate Amount `json:"eurRate"`
+	USDrate Amount `json:"usdRate"`
+	GBPrate Amount `json:"gbpRate"`
 }
 
 type TransactionRequest struct {
-	BaseCurrencyAmount float64 `json:"baseCurrencyAmount"`
-	ExtraFeeAmount     float64 `json:"extraFeePercentage"`
-	AreFeesIncluded    bool    `json:"areFeesIncluded"`
+	BaseCurrencyAmount Amount `json:"baseCurrencyAmount"`
+	// ExtraFeeAmount is the partner fee in percent of the amount, the API
+	// takes it as extraFeePercentage
+	ExtraFeeAmount  Amount `json:"extraFeePercentage"`
+	AreFeesIncluded bool   `json:"areFeesIncluded"`
 
 	WalletAddress    string `json:"walletAddress"`
 	WalletAddressTag string `json:"walletAddressTag,omitempty"`
@@ -229,4 +273,9 @@ type TransactionRequest struct {
 
 	TokenID string `json:"tokenId"`
 	CardID  string `json:"cardId"`
+
+	// ExternalTransactionID identifies the transaction on the partner side,
+	// it is generated by CreateTransaction if empty and returned even on the
+	// failure. Find the transaction by TransactionByExternalID after timeouts.
+	ExternalTransactionID string `json:"externalTransactionId,omitempty"`
 }
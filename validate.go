@@ -0,0 +1,203 @@
+package moonpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sg3des/moonpay/address"
+)
+
+// limitBuyCard is the type of the limit applied to purchases by card
+const limitBuyCard = "buy_credit_debit_card"
+
+// ValidationError is returned if the request is rejected before sending to the
+// API, it is matched by errors.Is to ErrValidation and to ErrLimitExceeded if
+// the amount exceeds the customer limits.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var ss []string
+	for _, fe := range e.Errors {
+		for _, msg := range fe.Constraints {
+			ss = append(ss, msg)
+		}
+	}
+
+	return strings.Join(ss, "; ")
+}
+
+// Is reports whether the error is of the target kind
+func (e *ValidationError) Is(target error) bool {
+	switch target {
+	case ErrValidation:
+		return true
+	case ErrLimitExceeded:
+		for _, fe := range e.Errors {
+			if _, ok := fe.Constraints["isWithinLimit"]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Field returns messages of the constraints violated by the property
+func (e *ValidationError) Field(property string) []string {
+	return fieldMessages(e.Errors, property)
+}
+
+// Fields returns violated constraints messages grouped by the property
+func (e *ValidationError) Fields() map[string][]string {
+	return fieldsMessages(e.Errors)
+}
+
+func (e *ValidationError) add(property string, value interface{}, constraint, format string, a ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{
+		Value:       value,
+		Property:    property,
+		Constraints: map[string]string{constraint: fmt.Sprintf(format, a...)},
+	})
+}
+
+// ValidateTransaction checks the transaction request as the API does: the
+// currencies are supported, the wallet address and tag match the currency
+// including the address checksum, exactly one of the token or the card is
+// specified and the amount converted to EUR is within the customer limits.
+// Returns *ValidationError if the request is invalid.
+func (m *MoonpayCustomer) ValidateTransaction(data TransactionRequest) error {
+	return m.ValidateTransactionContext(context.Background(), data)
+}
+
+// ValidateTransactionContext is like ValidateTransaction but with the context.
+func (m *MoonpayCustomer) ValidateTransactionContext(ctx context.Context, data TransactionRequest) error {
+	currencies, err := m.Catalog().Currencies(ctx)
+	if err != nil {
+		return err
+	}
+	customer, err := m.InfoContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	verr := validateTransaction(data, currencies, customer.LiveMode)
+	if len(verr.Errors) == 0 {
+		if err := m.validateLimits(ctx, data, verr); err != nil {
+			return err
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+// validateTransaction checks the request against the currencies
+func validateTransaction(data TransactionRequest, currencies []Currency, liveMode bool) *ValidationError {
+	verr := new(ValidationError)
+
+	base, ok := currencyByCode(currencies, data.BaseCurrencyCode)
+	if !ok || base.Type != "fiat" || base.IsSuspended {
+		verr.add("baseCurrencyCode", data.BaseCurrencyCode, "isSupported", "baseCurrencyCode must be a supported fiat currency")
+	}
+
+	cur, ok := currencyByCode(currencies, data.CurrencyCode)
+	switch {
+	case !ok || cur.Type != "crypto":
+		verr.add("currencyCode", data.CurrencyCode, "isSupported", "currencyCode must be a supported cryptocurrency")
+	case cur.IsSuspended:
+		verr.add("currencyCode", data.CurrencyCode, "isNotSuspended", "%s is suspended", strings.ToUpper(cur.Code))
+	default:
+		if err := address.Validate(cur.AddressFormat(), data.WalletAddress, liveMode); err != nil {
+			verr.add("walletAddress", data.WalletAddress, "isWalletAddress", "walletAddress must be a valid %s address: %v", strings.ToUpper(cur.Code), err)
+		}
+
+		if err := address.ValidateTag(cur.AddressFormat(), data.WalletAddressTag); err != nil {
+			constraint := "isAddressTag"
+			switch {
+			case errors.Is(err, address.ErrTagRequired):
+				constraint = "isNotEmpty"
+			case errors.Is(err, address.ErrTagUnsupported):
+				constraint = "isEmpty"
+			}
+			verr.add("walletAddressTag", data.WalletAddressTag, constraint, "walletAddressTag is invalid: %v", err)
+		}
+	}
+
+	if data.BaseCurrencyAmount.Sign() <= 0 {
+		verr.add("baseCurrencyAmount", data.BaseCurrencyAmount, "isPositive", "baseCurrencyAmount must be a positive number")
+	}
+	if (data.TokenID == "") == (data.CardID == "") {
+		verr.add("cardId", data.CardID, "isExclusive", "exactly one of cardId or tokenId must be specified")
+	}
+
+	return verr
+}
+
+// validateLimits checks the amount against the remaining limits of the
+// customer, the limits are in EUR. Amounts in other currencies are converted by
+// the prices of the cryptocurrency, the request is invalid if there are no
+// prices to convert.
+func (m *MoonpayCustomer) validateLimits(ctx context.Context, data TransactionRequest, verr *ValidationError) error {
+	limits, err := m.LimitsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range limits.Limits {
+		if l.Type != limitBuyCard {
+			continue
+		}
+
+		amount := data.BaseCurrencyAmount
+		if !strings.EqualFold(data.BaseCurrencyCode, "eur") {
+			prices, err := m.CurrencyPriceContext(ctx, data.CurrencyCode)
+			if err != nil {
+				return err
+			}
+
+			rate, eur := prices[strings.ToUpper(data.BaseCurrencyCode)], prices["EUR"]
+			if rate.Sign() <= 0 || eur.Sign() <= 0 {
+				verr.add("baseCurrencyAmount", data.BaseCurrencyAmount, "isConvertible", "baseCurrencyAmount cannot be converted to EUR to check the limits, no %s price of %s", strings.ToUpper(data.BaseCurrencyCode), strings.ToUpper(data.CurrencyCode))
+				return nil
+			}
+			amount = amount.Mul(eur).Div(rate, 2)
+		}
+
+		switch {
+		case amount.Cmp(NewAmount(int64(l.DailyLimitRemaining), 0)) > 0:
+			verr.add("baseCurrencyAmount", data.BaseCurrencyAmount, "isWithinLimit", "baseCurrencyAmount exceeds the remaining daily limit of %d EUR", l.DailyLimitRemaining)
+		case amount.Cmp(NewAmount(int64(l.MonthlyLimitRemaining), 0)) > 0:
+			verr.add("baseCurrencyAmount", data.BaseCurrencyAmount, "isWithinLimit", "baseCurrencyAmount exceeds the remaining monthly limit of %d EUR", l.MonthlyLimitRemaining)
+		}
+	}
+
+	return nil
+}
+
+func currencyByCode(currencies []Currency, code string) (Currency, bool) {
+	for _, c := range currencies {
+		if strings.EqualFold(c.Code, code) {
+			return c, true
+		}
+	}
+
+	return Currency{}, false
+}
+
+// AddressFormat returns the address format of the currency for the address
+// validation
+func (c Currency) AddressFormat() address.Currency {
+	return address.Currency{
+		Code:                c.Code,
+		AddressRegex:        c.AddressRegex,
+		TestnetAddressRegex: c.TestnetAddressRegex,
+		SupportsAddressTag:  c.SupportsAddressTag,
+		AddressTagRegex:     c.AddressTagRegex,
+	}
+}
@@ -0,0 +1,79 @@
+package moonpay
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+func TestValidateTransaction(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	customer := New(srv.PublishableKey, WithBaseURL(srv.APIURL())).Customer(srv.Login("validate@example.com"))
+
+	valid := TransactionRequest{
+		BaseCurrencyAmount: NewAmount(100, 0),
+		BaseCurrencyCode:   "usd",
+		CurrencyCode:       "xrp",
+		WalletAddress:      "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh",
+		WalletAddressTag:   "12345",
+		CardID:             "card",
+	}
+	if err := customer.ValidateTransaction(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		modify   func(*TransactionRequest)
+		property string
+	}{
+		{"unknown currency", func(r *TransactionRequest) { r.CurrencyCode = "doge" }, "currencyCode"},
+		{"suspended currency", func(r *TransactionRequest) { r.CurrencyCode = "dash" }, "currencyCode"},
+		{"fiat base currency", func(r *TransactionRequest) { r.BaseCurrencyCode = "btc" }, "baseCurrencyCode"},
+		{"invalid address", func(r *TransactionRequest) { r.WalletAddress = "invalid" }, "walletAddress"},
+		{"address typo", func(r *TransactionRequest) { r.WalletAddress = "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTj" }, "walletAddress"},
+		{"missing tag", func(r *TransactionRequest) { r.WalletAddressTag = "" }, "walletAddressTag"},
+		{"invalid tag", func(r *TransactionRequest) { r.WalletAddressTag = "tag" }, "walletAddressTag"},
+		{"card and token", func(r *TransactionRequest) { r.TokenID = "token" }, "cardId"},
+		{"no card", func(r *TransactionRequest) { r.CardID = "" }, "cardId"},
+		{"limit", func(r *TransactionRequest) { r.BaseCurrencyAmount = NewAmount(5000, 0) }, "baseCurrencyAmount"},
+		{"limit eur", func(r *TransactionRequest) { r.BaseCurrencyCode, r.BaseCurrencyAmount = "eur", NewAmount(5000, 0) }, "baseCurrencyAmount"},
+	}
+
+	for _, c := range cases {
+		data := valid
+		c.modify(&data)
+
+		err := customer.ValidateTransaction(data)
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: expected validation error, got %v", c.name, err)
+			continue
+		}
+
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: unexpected error type %T", c.name, err)
+			continue
+		}
+		if len(verr.Field(c.property)) == 0 {
+			t.Errorf("%s: expected error of %s, got %v", c.name, c.property, verr.Fields())
+		}
+		if limited := errors.Is(err, ErrLimitExceeded); limited != strings.HasPrefix(c.name, "limit") {
+			t.Errorf("%s: unexpected ErrLimitExceeded match %v", c.name, limited)
+		}
+	}
+
+	// the amount without the price to convert it to EUR is rejected
+	prices := moonpaytest.Prices()
+	delete(prices["XRP"], "USD")
+	srv.SetPrices(prices)
+	err := customer.ValidateTransaction(valid)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Field("baseCurrencyAmount")) == 0 || errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected validation error of the conversion, got %v", err)
+	}
+}
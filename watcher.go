@@ -0,0 +1,292 @@
+package moonpay
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Default polling intervals of the Watcher
+const (
+	DefaultWatchMinInterval = 2 * time.Second
+	DefaultWatchMaxInterval = time.Minute
+)
+
+// WatchEvent is the change of the watched transaction or the polling error
+type WatchEvent struct {
+	Transaction Transaction
+
+	// Previous is the previous snapshot, nil for the first one
+	Previous *Transaction
+
+	// Err is the error of polling, the transaction is not watched anymore if
+	// it is not found
+	Err error
+}
+
+// Watcher polls transactions of many customers until they reach the terminal
+// status. Snapshots are polled often after the change and the interval grows
+// while the transaction is unchanged. Unchanged and stale snapshots are
+// skipped, the changes are delivered to the Handler or to the Events channel.
+type Watcher struct {
+	// MinInterval and MaxInterval bound the polling interval of each
+	// transaction, they must be set before Run. Not positive intervals are
+	// replaced by the defaults.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+
+	// Concurrency is the maximum number of simultaneous requests
+	Concurrency int
+
+	// Handler receives events if set, otherwise events are sent to the
+	// channel returned by Events
+	Handler func(WatchEvent)
+
+	mu      sync.Mutex
+	watched map[uuid.UUID]*watched
+	events  chan WatchEvent
+	wake    chan struct{}
+}
+
+type watched struct {
+	customer *MoonpayCustomer
+	id       uuid.UUID
+	last     *Transaction
+	interval time.Duration
+	next     time.Time
+	polling  bool
+}
+
+// NewWatcher returns the watcher with default intervals
+func NewWatcher() *Watcher {
+	return &Watcher{
+		MinInterval: DefaultWatchMinInterval,
+		MaxInterval: DefaultWatchMaxInterval,
+		Concurrency: 4,
+		watched:     make(map[uuid.UUID]*watched),
+		events:      make(chan WatchEvent, 16),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Watch starts polling of the customer transaction, it may be called before or
+// while Run is running
+func (w *Watcher) Watch(customer *MoonpayCustomer, id uuid.UUID) {
+	w.mu.Lock()
+	w.watched[id] = &watched{customer: customer, id: id, interval: w.MinInterval}
+	w.mu.Unlock()
+
+	w.notify()
+}
+
+// Unwatch stops polling of the transaction
+func (w *Watcher) Unwatch(id uuid.UUID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.watched, id)
+}
+
+// Len returns the number of watched transactions
+func (w *Watcher) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.watched)
+}
+
+// Events returns the channel of events, it is closed when Run returns
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Run polls transactions until the context is done, it may be called once
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+
+	w.mu.Lock()
+	if w.MinInterval <= 0 {
+		w.MinInterval = DefaultWatchMinInterval
+	}
+	if w.MaxInterval <= 0 {
+		w.MaxInterval = DefaultWatchMaxInterval
+	}
+	if w.MaxInterval < w.MinInterval {
+		w.MaxInterval = w.MinInterval
+	}
+	w.mu.Unlock()
+
+	concurrency := w.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		due, wait := w.due(time.Now())
+		for _, item := range due {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			wg.Add(1)
+			go func(item *watched) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				w.poll(ctx, item)
+			}(item)
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-w.wake:
+			t.Stop()
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// due returns transactions to poll and marks them polling, and the time until
+// the next poll
+func (w *Watcher) due(now time.Time) ([]*watched, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var due []*watched
+	wait := w.MaxInterval
+	for _, item := range w.watched {
+		if item.polling {
+			continue
+		}
+		if d := item.next.Sub(now); d > 0 {
+			if d < wait {
+				wait = d
+			}
+			continue
+		}
+
+		item.polling = true
+		due = append(due, item)
+	}
+
+	return due, wait
+}
+
+// poll requests the transaction and delivers the event if it is changed
+func (w *Watcher) poll(ctx context.Context, item *watched) {
+	tx, err := item.customer.TransactionContext(ctx, item.id)
+	if ctx.Err() != nil {
+		return
+	}
+
+	event, changed := w.update(item, tx, err)
+	w.notify()
+
+	if changed {
+		w.deliver(ctx, event)
+	}
+}
+
+// update applies the polled snapshot, returns the event and whether it should
+// be delivered
+func (w *Watcher) update(item *watched, tx Transaction, err error) (WatchEvent, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	item.polling = false
+	event := WatchEvent{Previous: item.last, Err: err}
+
+	switch {
+	case err != nil:
+		if item.last != nil {
+			event.Transaction = *item.last
+		}
+		if errors.Is(err, ErrNotFound) {
+			w.remove(item)
+		}
+		w.backoff(item)
+		return event, true
+
+	case item.last != nil && sameSnapshot(*item.last, tx):
+		w.backoff(item)
+		return event, false
+
+	case item.last != nil:
+		if err := ValidateTransition(*item.last, tx); err != nil {
+			w.backoff(item)
+
+			// stale snapshots are skipped, impossible transitions are reported
+			event.Transaction, event.Err = tx, err
+			return event, errors.Is(err, ErrInvalidTransition)
+		}
+	}
+
+	event.Transaction = tx
+	item.last = &tx
+	item.interval = w.MinInterval
+	item.next = time.Now().Add(item.interval)
+
+	if tx.Status.IsTerminal() {
+		w.remove(item)
+	}
+
+	return event, true
+}
+
+// backoff doubles the polling interval of the unchanged transaction
+func (w *Watcher) backoff(item *watched) {
+	item.interval *= 2
+	if item.interval > w.MaxInterval {
+		item.interval = w.MaxInterval
+	}
+	if item.interval <= 0 {
+		item.interval = w.MinInterval
+	}
+	item.next = time.Now().Add(item.interval)
+}
+
+// remove stops watching the item unless it has been replaced by Watch
+func (w *Watcher) remove(item *watched) {
+	if w.watched[item.id] == item {
+		delete(w.watched, item.id)
+	}
+}
+
+func (w *Watcher) deliver(ctx context.Context, event WatchEvent) {
+	if w.Handler != nil {
+		w.Handler(event)
+		return
+	}
+
+	select {
+	case w.events <- event:
+	case <-ctx.Done():
+	}
+}
+
+func (w *Watcher) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// sameSnapshot reports whether the polled transaction is unchanged
+func sameSnapshot(a, b Transaction) bool {
+	return a.Status == b.Status &&
+		a.FailureReason == b.FailureReason &&
+		a.CryptoTransactionId == b.CryptoTransactionId &&
+		a.UpdatedAt.Equal(b.UpdatedAt)
+}
@@ -0,0 +1,144 @@
+package moonpay
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sg3des/moonpay/moonpaytest"
+)
+
+func TestWatcher(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	m := New(srv.PublishableKey, WithBaseURL(srv.APIURL()))
+	create := func(email string) (*MoonpayCustomer, Transaction) {
+		customer := m.Customer(srv.Login(email))
+
+		token, err := m.CreateToken(TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := customer.CreateTransaction(TransactionRequest{
+			BaseCurrencyAmount: NewAmount(50, 0),
+			BaseCurrencyCode:   "eur",
+			CurrencyCode:       "btc",
+			WalletAddress:      "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+			TokenID:            token.ID.String(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return customer, tx
+	}
+
+	alice, tx1 := create("alice@example.com")
+	bob, tx2 := create("bob@example.com")
+
+	w := NewWatcher()
+	w.MinInterval = 5 * time.Millisecond
+	w.MaxInterval = 20 * time.Millisecond
+	w.Watch(alice, tx1.ID)
+	w.Watch(bob, tx2.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go w.Run(ctx)
+
+	statuses := make(map[uuid.UUID][]TransactionStatus)
+	wait := func(n int) {
+		for i := 0; i < n; i++ {
+			select {
+			case e := <-w.Events():
+				if e.Err != nil {
+					t.Fatal(e.Err)
+				}
+				statuses[e.Transaction.ID] = append(statuses[e.Transaction.ID], e.Transaction.Status)
+			case <-ctx.Done():
+				t.Fatalf("events are not received: %v", statuses)
+			}
+		}
+	}
+
+	// the first snapshots
+	wait(2)
+
+	// unchanged snapshots are not delivered
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case e := <-w.Events():
+		t.Fatalf("unexpected event of unchanged transaction: %+v", e)
+	default:
+	}
+
+	srv.SetTransactionStatus(tx1.ID, "completed", "")
+	srv.SetTransactionStatus(tx2.ID, "failed", "cardDeclined")
+	wait(2)
+
+	if s := statuses[tx1.ID]; len(s) != 2 || s[1] != StatusCompleted {
+		t.Errorf("unexpected statuses of the first transaction: %v", s)
+	}
+	if s := statuses[tx2.ID]; len(s) != 2 || s[1] != StatusFailed {
+		t.Errorf("unexpected statuses of the second transaction: %v", s)
+	}
+	if n := w.Len(); n != 0 {
+		t.Errorf("%d transactions are still watched", n)
+	}
+
+	cancel()
+	for range w.Events() {
+	}
+}
+
+func TestWatcherDefaultIntervals(t *testing.T) {
+	srv := moonpaytest.NewServer()
+	defer srv.Close()
+
+	var polls int32
+	m := New(srv.PublishableKey,
+		WithBaseURL(srv.APIURL()),
+		WithTransport(transportFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method == "GET" && strings.Contains(r.URL.Path, "/transactions/") {
+				atomic.AddInt32(&polls, 1)
+			}
+			return http.DefaultTransport.RoundTrip(r)
+		})),
+	)
+	customer := m.Customer(srv.Login("intervals@example.com"))
+	token, err := m.CreateToken(TokenRequest{Number: "4111111111111111", ExpiryDate: "12/30", CVC: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := customer.CreateTransaction(TransactionRequest{
+		BaseCurrencyAmount: NewAmount(50, 0),
+		BaseCurrencyCode:   "eur",
+		CurrencyCode:       "btc",
+		WalletAddress:      "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		TokenID:            token.ID.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWatcher()
+	w.MinInterval, w.MaxInterval = 0, 0
+	w.Handler = func(WatchEvent) {}
+	w.Watch(customer, tx.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+
+	if w.MinInterval != DefaultWatchMinInterval || w.MaxInterval != DefaultWatchMaxInterval {
+		t.Errorf("unexpected intervals %s %s", w.MinInterval, w.MaxInterval)
+	}
+	if n := atomic.LoadInt32(&polls); n > 1 {
+		t.Errorf("transaction is polled %d times", n)
+	}
+}
@@ -0,0 +1,262 @@
+package moonpay
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Types of the webhook events
+const (
+	WebhookTransactionCreated   = "transaction_created"
+	WebhookTransactionUpdated   = "transaction_updated"
+	WebhookTransactionFailed    = "transaction_failed"
+	WebhookIdentityCheckUpdated = "identity_check_updated"
+)
+
+// WebhookSignatureHeader is the header containing signature of the webhook
+const WebhookSignatureHeader = "Moonpay-Signature-V2"
+
+const (
+	defaultWebhookTolerance = 5 * time.Minute
+	maxWebhookBodySize      = 1 << 20
+
+	webhookSignatureTimestampKey = "t"
+	webhookSignatureKey          = "s"
+)
+
+var (
+	// ErrWebhookSignature is returned when the webhook signature is missing or
+	// does not match the body
+	ErrWebhookSignature = errors.New("moonpay: invalid webhook signature")
+
+	// ErrWebhookExpired is returned when the webhook timestamp is out of the
+	// tolerance, it prevents replay attacks
+	ErrWebhookExpired = errors.New("moonpay: webhook timestamp is out of tolerance")
+)
+
+// WebhookEvent is a notification sent by MoonPay on the change of the object.
+// Transaction is set for the transaction_* events and IdentityCheck for the
+// identity_check_updated event.
+// https://www.moonpay.io/api_reference/v3#webhooks
+type WebhookEvent struct {
+	Type               string
+	ExternalCustomerID string
+	Data               json.RawMessage
+
+	Transaction   *Transaction   `json:"-"`
+	IdentityCheck *IdentityCheck `json:"-"`
+}
+
+// IdentityCheck objects represent the verification of the customer identity.
+type IdentityCheck struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	CustomerID uuid.UUID `json:"customerId"`
+	Status     string    `json:"status"`
+	Result     string    `json:"result"`
+
+	Customer *Customer `json:"customer,omitempty"`
+}
+
+// WebhookHandler is the http.Handler receiving MoonPay webhooks, it verifies
+// the signature, decodes the event and dispatches it to registered callbacks.
+// If any callback returns an error the handler responds with 500 status, so
+// MoonPay retries the delivery later.
+type WebhookHandler struct {
+	// Tolerance is the maximum age of the signature, 5 minutes by default
+	Tolerance time.Duration
+
+	secret []byte
+
+	mu       sync.RWMutex
+	handlers map[string][]func(*WebhookEvent) error
+}
+
+// NewWebhookHandler creates handler verifying webhooks by the webhook key
+func NewWebhookHandler(secret string) *WebhookHandler {
+	return &WebhookHandler{
+		Tolerance: defaultWebhookTolerance,
+		secret:    []byte(secret),
+		handlers:  make(map[string][]func(*WebhookEvent) error),
+	}
+}
+
+// On registers callback for the events of specified type
+func (h *WebhookHandler) On(typ string, fn func(*WebhookEvent) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handlers[typ] = append(h.handlers[typ], fn)
+}
+
+// OnTransactionCreated registers callback for the transaction_created events
+func (h *WebhookHandler) OnTransactionCreated(fn func(Transaction) error) {
+	h.onTransaction(WebhookTransactionCreated, fn)
+}
+
+// OnTransactionUpdated registers callback for the transaction_updated events
+func (h *WebhookHandler) OnTransactionUpdated(fn func(Transaction) error) {
+	h.onTransaction(WebhookTransactionUpdated, fn)
+}
+
+// OnTransactionFailed registers callback for the transaction_failed events
+func (h *WebhookHandler) OnTransactionFailed(fn func(Transaction) error) {
+	h.onTransaction(WebhookTransactionFailed, fn)
+}
+
+// OnIdentityCheckUpdated registers callback for the identity_check_updated
+// events
+func (h *WebhookHandler) OnIdentityCheckUpdated(fn func(IdentityCheck) error) {
+	h.On(WebhookIdentityCheckUpdated, func(e *WebhookEvent) error {
+		return fn(*e.IdentityCheck)
+	})
+}
+
+func (h *WebhookHandler) onTransaction(typ string, fn func(Transaction) error) {
+	h.On(typ, func(e *WebhookEvent) error {
+		return fn(*e.Transaction)
+	})
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	e, err := h.Parse(r.Header.Get(WebhookSignatureHeader), body)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrWebhookSignature) || errors.Is(err, ErrWebhookExpired) {
+			status = http.StatusUnauthorized
+		}
+
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if err := h.dispatch(e); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Parse verifies the signature header and decodes the body to the event
+func (h *WebhookHandler) Parse(signature string, body []byte) (*WebhookEvent, error) {
+	if err := h.Verify(signature, body); err != nil {
+		return nil, err
+	}
+
+	var e WebhookEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("moonpay: failed decode webhook: %w", err)
+	}
+
+	var v interface{}
+	switch e.Type {
+	case WebhookTransactionCreated, WebhookTransactionUpdated, WebhookTransactionFailed:
+		e.Transaction = new(Transaction)
+		v = e.Transaction
+	case WebhookIdentityCheckUpdated:
+		e.IdentityCheck = new(IdentityCheck)
+		v = e.IdentityCheck
+	default:
+		return &e, nil
+	}
+
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return nil, fmt.Errorf("moonpay: failed decode %s webhook data: %w", e.Type, err)
+	}
+
+	return &e, nil
+}
+
+// Verify checks the signature header in the `t=timestamp,s=signature` format
+func (h *WebhookHandler) Verify(signature string, body []byte) error {
+	var ts, sig string
+	for _, kv := range strings.Split(signature, ",") {
+		parts := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case webhookSignatureTimestampKey:
+			ts = parts[1]
+		case webhookSignatureKey:
+			sig = parts[1]
+		}
+	}
+
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrWebhookSignature
+	}
+
+	expected := webhookSignature(h.secret, ts, body)
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return ErrWebhookSignature
+	}
+
+	if h.Tolerance > 0 {
+		if d := time.Since(time.Unix(sec, 0)); d > h.Tolerance || d < -h.Tolerance {
+			return ErrWebhookExpired
+		}
+	}
+
+	return nil
+}
+
+func (h *WebhookHandler) dispatch(e *WebhookEvent) error {
+	h.mu.RLock()
+	handlers := h.handlers[e.Type]
+	h.mu.RUnlock()
+
+	for _, fn := range handlers {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SignWebhook returns the signature header value for the body, it is useful
+// to test webhook receivers.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return fmt.Sprintf("%s=%s,%s=%s",
+		webhookSignatureTimestampKey, ts,
+		webhookSignatureKey, webhookSignature([]byte(secret), ts, body),
+	)
+}
+
+func webhookSignature(secret []byte, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,85 @@
+package moonpay
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "wk_test_secret"
+
+var testWebhookBody = []byte(`{
+	"data": {
+		"id": "5ea2c2e4-a2b8-4d3e-b7b0-02a0ad9ac63a",
+		"baseCurrencyAmount": 50,
+		"quoteCurrencyAmount": 0.0051,
+		"status": "completed",
+		"walletAddress": "tb1q6rz28mcfaxtmd6v789l9rrlrusdprr9pqcpvkl"
+	},
+	"type": "transaction_updated",
+	"externalCustomerId": "ext-1"
+}`)
+
+func TestWebhookHandler(t *testing.T) {
+	h := NewWebhookHandler(testWebhookSecret)
+
+	var received Transaction
+	h.OnTransactionUpdated(func(tx Transaction) error {
+		received = tx
+		return nil
+	})
+
+	r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(testWebhookBody))
+	r.Header.Set(WebhookSignatureHeader, SignWebhook(testWebhookSecret, time.Now(), testWebhookBody))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected status %d: %s", w.Code, w.Body)
+	}
+	if received.Status != StatusCompleted || received.WalletAddress == "" {
+		t.Errorf("transaction is not decoded: %+v", received)
+	}
+}
+
+func TestWebhookHandlerCallbackError(t *testing.T) {
+	h := NewWebhookHandler(testWebhookSecret)
+	h.On(WebhookTransactionUpdated, func(*WebhookEvent) error {
+		return errors.New("database is down")
+	})
+
+	r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(testWebhookBody))
+	r.Header.Set(WebhookSignatureHeader, SignWebhook(testWebhookSecret, time.Now(), testWebhookBody))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status %d", w.Code)
+	}
+}
+
+func TestWebhookVerify(t *testing.T) {
+	h := NewWebhookHandler(testWebhookSecret)
+
+	cases := []struct {
+		name      string
+		signature string
+		body      []byte
+		err       error
+	}{
+		{"valid", SignWebhook(testWebhookSecret, time.Now(), testWebhookBody), testWebhookBody, nil},
+		{"missing", "", testWebhookBody, ErrWebhookSignature},
+		{"wrong secret", SignWebhook("other", time.Now(), testWebhookBody), testWebhookBody, ErrWebhookSignature},
+		{"tampered body", SignWebhook(testWebhookSecret, time.Now(), testWebhookBody), []byte(`{"type":"transaction_updated"}`), ErrWebhookSignature},
+		{"expired", SignWebhook(testWebhookSecret, time.Now().Add(-time.Hour), testWebhookBody), testWebhookBody, ErrWebhookExpired},
+	}
+
+	for _, c := range cases {
+		if err := h.Verify(c.signature, c.body); !errors.Is(err, c.err) {
+			t.Errorf("%s: expected error %v, got %v", c.name, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package moonpay
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+const (
+	buyWidgetAddr         = "https://buy.moonpay.com"
+	buyWidgetSandboxAddr  = "https://buy-sandbox.moonpay.com"
+	sellWidgetAddr        = "https://sell.moonpay.com"
+	sellWidgetSandboxAddr = "https://sell-sandbox.moonpay.com"
+)
+
+var (
+	// ErrNoSecretKey is returned when the widget URL with the wallet address is
+	// built without the secret key
+	ErrNoSecretKey = errors.New("moonpay: secret key is required to sign the widget URL")
+
+	// ErrURLSignature is returned by VerifyURL if the signature does not match
+	ErrURLSignature = errors.New("moonpay: invalid URL signature")
+)
+
+// WithSecretKey sets the secret key used to sign widget URLs
+func WithSecretKey(key string) Option {
+	return func(m *Moonpay) {
+		m.secret = key
+	}
+}
+
+// WithWidgetURL sets addresses of the buy and sell widgets, by default the
+// sandbox widgets are used for the test keys
+func WithWidgetURL(buy, sell string) Option {
+	return func(m *Moonpay) {
+		m.buyWidget = buy
+		m.sellWidget = sell
+	}
+}
+
+// BuyWidget is parameters of the hosted widget for purchase of cryptocurrency
+// https://www.moonpay.io/docs/widget
+type BuyWidget struct {
+	// CurrencyCode is the code of the cryptocurrency to buy
+	CurrencyCode       string
+	WalletAddress      string
+	WalletAddressTag   string
+	BaseCurrencyCode   string
+	BaseCurrencyAmount Amount
+
+	Email                 string
+	ExternalCustomerID    string
+	ExternalTransactionID string
+	RedirectURL           string
+
+	// Color is the widget accent color in the #RRGGBB format
+	Color string
+}
+
+// SellWidget is parameters of the hosted widget for sale of cryptocurrency
+type SellWidget struct {
+	// BaseCurrencyCode is the code of the cryptocurrency to sell
+	BaseCurrencyCode    string
+	BaseCurrencyAmount  Amount
+	QuoteCurrencyCode   string
+	RefundWalletAddress string
+
+	Email                 string
+	ExternalCustomerID    string
+	ExternalTransactionID string
+	RedirectURL           string
+
+	// Color is the widget accent color in the #RRGGBB format
+	Color string
+}
+
+// BuyURL returns signed URL of the buy widget. The secret key is required if
+// the wallet address is specified.
+func (m *Moonpay) BuyURL(w BuyWidget) (string, error) {
+	q := url.Values{"apiKey": {m.pubkey}}
+	setParam(q, "currencyCode", w.CurrencyCode)
+	setParam(q, "walletAddress", w.WalletAddress)
+	setParam(q, "walletAddressTag", w.WalletAddressTag)
+	setParam(q, "baseCurrencyCode", w.BaseCurrencyCode)
+	setParam(q, "baseCurrencyAmount", formatAmount(w.BaseCurrencyAmount))
+	setParam(q, "email", w.Email)
+	setParam(q, "externalCustomerId", w.ExternalCustomerID)
+	setParam(q, "externalTransactionId", w.ExternalTransactionID)
+	setParam(q, "redirectURL", w.RedirectURL)
+	setParam(q, "colorCode", w.Color)
+
+	addr := m.buyWidget
+	if addr == "" {
+		addr = buyWidgetAddr
+		if m.testMode() {
+			addr = buyWidgetSandboxAddr
+		}
+	}
+
+	return m.widgetURL(addr, q, w.WalletAddress != "")
+}
+
+// SellURL returns signed URL of the sell widget. The secret key is required
+// if the refund wallet address is specified.
+func (m *Moonpay) SellURL(w SellWidget) (string, error) {
+	q := url.Values{"apiKey": {m.pubkey}}
+	setParam(q, "baseCurrencyCode", w.BaseCurrencyCode)
+	setParam(q, "baseCurrencyAmount", formatAmount(w.BaseCurrencyAmount))
+	setParam(q, "quoteCurrencyCode", w.QuoteCurrencyCode)
+	setParam(q, "refundWalletAddress", w.RefundWalletAddress)
+	setParam(q, "email", w.Email)
+	setParam(q, "externalCustomerId", w.ExternalCustomerID)
+	setParam(q, "externalTransactionId", w.ExternalTransactionID)
+	setParam(q, "redirectURL", w.RedirectURL)
+	setParam(q, "colorCode", w.Color)
+
+	addr := m.sellWidget
+	if addr == "" {
+		addr = sellWidgetAddr
+		if m.testMode() {
+			addr = sellWidgetSandboxAddr
+		}
+	}
+
+	return m.widgetURL(addr, q, w.RefundWalletAddress != "")
+}
+
+func (m *Moonpay) widgetURL(addr string, q url.Values, signRequired bool) (string, error) {
+	rawurl := addr + "?" + q.Encode()
+	if m.secret == "" {
+		if signRequired {
+			return "", ErrNoSecretKey
+		}
+		return rawurl, nil
+	}
+
+	return SignURL(rawurl, m.secret)
+}
+
+// testMode reports whether the publishable key is the test one
+func (m *Moonpay) testMode() bool {
+	return strings.HasPrefix(m.pubkey, "pk_test_")
+}
+
+// SignURL appends signature parameter to the widget URL, the signature is
+// base64 encoded HMAC-SHA256 of the query string.
+// https://www.moonpay.io/docs/widget#signing-the-url
+func SignURL(rawurl, secret string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+
+	sig := urlSignature(u.RawQuery, secret)
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += "signature=" + url.QueryEscape(sig)
+
+	return u.String(), nil
+}
+
+// VerifyURL checks the signature parameter of the widget URL
+func VerifyURL(rawurl, secret string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+
+	var sig string
+	var params []string
+	for _, p := range strings.Split(u.RawQuery, "&") {
+		if strings.HasPrefix(p, "signature=") {
+			sig, err = url.QueryUnescape(strings.TrimPrefix(p, "signature="))
+			if err != nil {
+				return ErrURLSignature
+			}
+			continue
+		}
+		params = append(params, p)
+	}
+
+	expected := urlSignature(strings.Join(params, "&"), secret)
+	if sig == "" || !hmac.Equal([]byte(sig), []byte(expected)) {
+		return ErrURLSignature
+	}
+
+	return nil
+}
+
+func urlSignature(query, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("?" + query))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func setParam(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+func formatAmount(v Amount) string {
+	if v.IsZero() {
+		return ""
+	}
+
+	return v.String()
+}
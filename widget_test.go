@@ -0,0 +1,57 @@
+package moonpay
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBuyURL(t *testing.T) {
+	m := New("pk_test_key", WithSecretKey("sk_test_key"))
+
+	rawurl, err := m.BuyURL(BuyWidget{
+		CurrencyCode:       "btc",
+		WalletAddress:      "tb1q6rz28mcfaxtmd6v789l9rrlrusdprr9pqcpvkl",
+		BaseCurrencyAmount: NewAmount(50, 0),
+		Email:              "john@example.com",
+		RedirectURL:        "https://example.com/done?order=1",
+	})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if !strings.HasPrefix(rawurl, buyWidgetSandboxAddr+"?") {
+		t.Errorf("expected sandbox widget for the test key: %s", rawurl)
+	}
+
+	u, _ := url.Parse(rawurl)
+	if q := u.Query(); q.Get("apiKey") != "pk_test_key" || q.Get("baseCurrencyAmount") != "50" || q.Get("signature") == "" {
+		t.Errorf("unexpected query: %s", u.RawQuery)
+	}
+
+	if err := VerifyURL(rawurl, "sk_test_key"); err != nil {
+		t.Error(err)
+	}
+
+	tampered := strings.Replace(rawurl, "tb1q6rz28", "tb1qxxxxx", 1)
+	if err := VerifyURL(tampered, "sk_test_key"); err != ErrURLSignature {
+		t.Errorf("tampered URL passed verification: %v", err)
+	}
+}
+
+func TestSellURL(t *testing.T) {
+	rawurl, err := New("pk_live_key").SellURL(SellWidget{BaseCurrencyCode: "eth", QuoteCurrencyCode: "eur"})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !strings.HasPrefix(rawurl, sellWidgetAddr+"?") {
+		t.Errorf("unexpected widget address: %s", rawurl)
+	}
+
+	_, err = New("pk_live_key").SellURL(SellWidget{BaseCurrencyCode: "eth", RefundWalletAddress: "0x0"})
+	if err != ErrNoSecretKey {
+		t.Errorf("expected ErrNoSecretKey, got %v", err)
+	}
+}